@@ -27,16 +27,36 @@ import "go.temporal.io/sdk/client"
 // Activities is a structure with bench activity functions.
 type Activities struct {
 	temporalClient client.Client
+	metrics        MetricsConfig
 }
 
 // NewActivities creates a new structure with bench activity functions.
-func NewActivities(temporalClient client.Client) *Activities {
-	return &Activities{temporalClient: temporalClient}
+func NewActivities(temporalClient client.Client, metrics MetricsConfig) *Activities {
+	return &Activities{temporalClient: temporalClient, metrics: metrics}
 }
 
 // benchTaskQueue is the queue used by worker to pull workflow and activity tasks
 const benchTaskQueue = "temporal-bench"
 
+const (
+	visibilityStandard = "standard"
+	visibilityAdvanced = "advanced"
+)
+
+// defaultRunSearchAttribute is the search attribute that holds the bench run ID
+const defaultRunSearchAttribute = "BenchRunId"
+
+// search attributes that tag the target workflows
+const (
+	stepSearchAttribute      = "BenchStep"
+	driverSearchAttribute    = "BenchDriver"
+	iterationSearchAttribute = "BenchIteration"
+	scenarioSearchAttribute  = "BenchScenario"
+)
+
+// warmupTag is part of the IDs of the workflows started by warm-up steps
+const warmupTag = "warmup"
+
 // TestError represents an error that should abort / fail the whole bench test
 type TestError struct {
 	Message string
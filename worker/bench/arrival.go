@@ -0,0 +1,124 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+const (
+	arrivalConstant = "constant"
+	arrivalPoisson  = "poisson"
+	arrivalBursty   = "bursty"
+)
+
+// arrivalProcess plans the start times of workflows on an open-loop timeline.
+type arrivalProcess interface {
+	// next returns the planned time of the start that follows the one planned at prev, given the average rate per second.
+	next(prev time.Time, rate float64) time.Time
+}
+
+// constantArrival spaces starts at perfectly even intervals.
+type constantArrival struct{}
+
+func (constantArrival) next(prev time.Time, rate float64) time.Time {
+	return prev.Add(time.Duration(float64(time.Second) / rate))
+}
+
+// poissonArrival draws exponentially distributed inter-arrival times, which makes the starts a Poisson process.
+// Each driver has its own source of randomness, so that the drivers of a step do not start in lockstep.
+type poissonArrival struct {
+	rnd *rand.Rand
+}
+
+func (p poissonArrival) next(prev time.Time, rate float64) time.Time {
+	return prev.Add(time.Duration(p.rnd.ExpFloat64() * float64(time.Second) / rate))
+}
+
+// newPoissonArrival seeds the arrival process of a driver from its base ID.
+func newPoissonArrival(baseID string) poissonArrival {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(baseID))
+	return poissonArrival{rnd: rand.New(rand.NewSource(int64(h.Sum64())))}
+}
+
+// burstyArrival alternates between on periods, when starts arrive at an increased rate, and off periods without any starts.
+// The rate during the on periods is scaled up so that the average rate over a whole cycle matches the requested rate.
+type burstyArrival struct {
+	origin time.Time
+	on     time.Duration
+	off    time.Duration
+}
+
+func (b burstyArrival) next(prev time.Time, rate float64) time.Time {
+	cycle := b.on + b.off
+	onRate := rate * float64(cycle) / float64(b.on)
+	next := prev.Add(time.Duration(float64(time.Second) / onRate))
+	if position := next.Sub(b.origin) % cycle; position >= b.on {
+		next = next.Add(cycle - position)
+	}
+	return next
+}
+
+func newArrivalProcess(request benchDriverActivityRequest, origin time.Time) (arrivalProcess, error) {
+	if err := validateArrival(request.Arrival, request.BurstOn, request.BurstOff); err != nil {
+		return nil, err
+	}
+	if request.Rate <= 0 && request.RampFromRate <= 0 && request.RampToRate <= 0 {
+		// an open-loop driver without a rate would start nothing and still succeed
+		return nil, fmt.Errorf("%q arrival of driver %d of step %d has no rate", request.Arrival, request.Driver, request.Step)
+	}
+	switch request.Arrival {
+	case arrivalPoisson:
+		return newPoissonArrival(request.BaseID), nil
+	case arrivalBursty:
+		return burstyArrival{origin: origin, on: request.BurstOn, off: request.BurstOff}, nil
+	default:
+		return constantArrival{}, nil
+	}
+}
+
+// validate checks the arrival of a step, an empty type stands for a rate-limited step.
+func (a *benchWorkflowRequestArrival) validate() error {
+	if a.Type == "" {
+		return nil
+	}
+	return validateArrival(a.Type, time.Duration(a.OnSeconds)*time.Second, time.Duration(a.OffSeconds)*time.Second)
+}
+
+func validateArrival(arrival string, on, off time.Duration) error {
+	switch arrival {
+	case arrivalConstant, arrivalPoisson:
+		return nil
+	case arrivalBursty:
+		if on <= 0 || off < 0 {
+			return fmt.Errorf("bursty arrival requires a positive on period, got on %v and off %v", on, off)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown arrival type %q", arrival)
+	}
+}
@@ -0,0 +1,88 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestConstantArrival(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, start.Add(100*time.Millisecond), constantArrival{}.next(start, 10))
+}
+
+func TestPoissonArrivalMeanGap(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := start
+	n := 10000
+	arrival := newPoissonArrival("driver")
+	for i := 0; i < n; i++ {
+		next = arrival.next(next, 100)
+	}
+	mean := next.Sub(start) / time.Duration(n)
+	assert.InDelta(t, float64(10*time.Millisecond), float64(mean), float64(time.Millisecond))
+}
+
+func TestBurstyArrivalSkipsOffPeriods(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	arrival := burstyArrival{origin: start, on: time.Second, off: 3 * time.Second}
+	next := start
+	count := 0
+	for next.Before(start.Add(40 * time.Second)) {
+		next = arrival.next(next, 10)
+		position := next.Sub(start) % (4 * time.Second)
+		assert.Less(t, position, time.Second)
+		count++
+	}
+	// the average rate of 10 per second is kept over whole cycles
+	assert.InDelta(t, 400, count, 2)
+}
+
+func TestNewArrivalProcessValidation(t *testing.T) {
+	_, err := newArrivalProcess(benchDriverActivityRequest{Arrival: "unknown"}, time.Now())
+	assert.Error(t, err)
+	_, err = newArrivalProcess(benchDriverActivityRequest{Arrival: arrivalBursty}, time.Now())
+	assert.Error(t, err)
+	_, err = newArrivalProcess(benchDriverActivityRequest{Arrival: arrivalBursty, BurstOn: time.Second, Rate: 0.5}, time.Now())
+	assert.NoError(t, err)
+	_, err = newArrivalProcess(benchDriverActivityRequest{Arrival: arrivalConstant}, time.Now())
+	assert.Error(t, err)
+	_, err = newArrivalProcess(benchDriverActivityRequest{Arrival: arrivalConstant, RampToRate: 10}, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestPoissonArrivalDiffersPerDriver(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, second := newPoissonArrival("run-0-0"), newPoissonArrival("run-0-1")
+	assert.NotEqual(t, first.next(start, 10), second.next(start, 10))
+}
+
+func TestArrivalValidate(t *testing.T) {
+	assert.NoError(t, (&benchWorkflowRequestArrival{}).validate())
+	assert.NoError(t, (&benchWorkflowRequestArrival{Type: arrivalPoisson}).validate())
+	assert.Error(t, (&benchWorkflowRequestArrival{Type: "periodic"}).validate())
+	assert.Error(t, (&benchWorkflowRequestArrival{Type: arrivalBursty, OffSeconds: 10}).validate())
+	assert.Error(t, (&benchWorkflowRequestArrival{Type: arrivalBursty, OnSeconds: 1, OffSeconds: -1}).validate())
+}
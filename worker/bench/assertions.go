@@ -0,0 +1,195 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	aggregationMax = "max"
+	aggregationAvg = "avg"
+)
+
+// assertionErrorType is the type of the non-retryable application error of a bench run that failed its assertions.
+const assertionErrorType = "AssertionError"
+
+type (
+	// benchVerdict is the outcome of the assertions of a bench run.
+	benchVerdict struct {
+		Passed     bool              `json:"passed"`
+		Assertions []assertionResult `json:"assertions"`
+	}
+
+	assertionResult struct {
+		// Name identifies the checked value, e.g. "latency.p99" or "metrics.historyCpu.max".
+		Name     string  `json:"name"`
+		Operator string  `json:"operator"`
+		Limit    float64 `json:"limit"`
+		// Actual is the checked value, nil when the run did not produce it.
+		Actual *float64 `json:"actual"`
+		Passed bool     `json:"passed"`
+	}
+)
+
+// validate checks the assertions against the names of the metrics of the request.
+func (a *benchWorkflowRequestAssertions) validate(metrics map[string]bool) error {
+	if _, ok := (latencyPercentiles{}).get(a.percentile()); !ok {
+		return errors.Errorf("unknown latency percentile %q", a.LatencyPercentile)
+	}
+	for _, m := range a.Metrics {
+		if !metrics[m.Metric] {
+			return errors.Errorf("unknown metric %q", m.Metric)
+		}
+		switch m.Aggregation {
+		case "", aggregationMax, aggregationAvg:
+		default:
+			return errors.Errorf("unknown aggregation %q of metric %q", m.Aggregation, m.Metric)
+		}
+		if m.Min == nil && m.Max == nil {
+			return errors.Errorf("assertion on metric %q must define min or max", m.Metric)
+		}
+	}
+	return nil
+}
+
+func (a *benchWorkflowRequestAssertions) percentile() string {
+	if a.LatencyPercentile == "" {
+		return defaultLatencyPercentile
+	}
+	return a.LatencyPercentile
+}
+
+// evaluate checks the report of the run against the assertions. A value that the run did not produce fails its
+// assertion, and the histogram intervals of the warm-up are not checked.
+func (a *benchWorkflowRequestAssertions) evaluate(summary benchSummary, histogram []histogramValue, metrics []metricValue) benchVerdict {
+	verdict := benchVerdict{Passed: true, Assertions: []assertionResult{}}
+	check := func(name, operator string, limit float64, actual *float64) {
+		r := assertionResult{Name: name, Operator: operator, Limit: limit, Actual: actual}
+		switch {
+		case actual == nil:
+		case operator == "<=":
+			r.Passed = *actual <= limit
+		case operator == ">=":
+			r.Passed = *actual >= limit
+		}
+		verdict.Passed = verdict.Passed && r.Passed
+		verdict.Assertions = append(verdict.Assertions, r)
+	}
+
+	if a.MaxLatencyMs > 0 {
+		var actual *float64
+		if latency, _ := summary.Latency.get(a.percentile()); summary.Latency.Count > 0 {
+			actual = &latency
+		}
+		check("latency."+a.percentile(), "<=", a.MaxLatencyMs, actual)
+	}
+	if a.MaxBacklog != nil {
+		// warm-up workflows still open after the warm-up do not count either
+		backlog := 0.0
+		for _, v := range histogram {
+			if measured := float64(v.Backlog - v.WarmupBacklog); measured > backlog {
+				backlog = measured
+			}
+		}
+		check("backlog", "<=", float64(*a.MaxBacklog), &backlog)
+	}
+	if a.MinThroughput > 0 {
+		throughput := summary.Throughput
+		check("throughput", ">=", a.MinThroughput, &throughput)
+	}
+	if a.MaxFailureRatio != nil {
+		var actual *float64
+		if summary.Workflows > 0 {
+			ratio := float64(summary.Statuses.failures()) / float64(summary.Workflows)
+			actual = &ratio
+		}
+		check("failureRatio", "<=", *a.MaxFailureRatio, actual)
+	}
+	for _, m := range a.Metrics {
+		name := fmt.Sprintf("metrics.%s.%s", m.Metric, m.aggregation())
+		actual := aggregateMetric(metrics, m.Metric, m.aggregation())
+		if m.Max != nil {
+			check(name, "<=", *m.Max, actual)
+		}
+		if m.Min != nil {
+			check(name, ">=", *m.Min, actual)
+		}
+	}
+	return verdict
+}
+
+func (m *benchWorkflowRequestMetricAssertion) aggregation() string {
+	if m.Aggregation == "" {
+		return aggregationMax
+	}
+	return m.Aggregation
+}
+
+// aggregateMetric aggregates the values of a metric over all intervals, or returns nil if it has no value.
+func aggregateMetric(values []metricValue, metric, aggregation string) *float64 {
+	var res float64
+	count := 0
+	for _, v := range values {
+		value, ok := v.get(metric)
+		if !ok {
+			continue
+		}
+		switch {
+		case aggregation == aggregationAvg:
+			res += value
+		case count == 0 || value > res:
+			res = value
+		}
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	if aggregation == aggregationAvg {
+		res /= float64(count)
+	}
+	return &res
+}
+
+// error returns the non-retryable error that describes the failed assertions, with the given details.
+func (v *benchVerdict) error(details ...interface{}) error {
+	var failed []string
+	for _, r := range v.Assertions {
+		if r.Passed {
+			continue
+		}
+		actual := "no value"
+		if r.Actual != nil {
+			actual = fmt.Sprintf("%g", *r.Actual)
+		}
+		failed = append(failed, fmt.Sprintf("%s is %s, expected %s %g", r.Name, actual, r.Operator, r.Limit))
+	}
+	return temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("%d of %d assertions failed: %s", len(failed), len(v.Assertions), strings.Join(failed, "; ")),
+		assertionErrorType, nil, details...)
+}
@@ -0,0 +1,93 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAssertionsPass(t *testing.T) {
+	maxBacklog := 10
+	maxFailureRatio := 0.01
+	maxCpu := 2000.0
+	a := benchWorkflowRequestAssertions{
+		MaxLatencyMs:    500,
+		MaxBacklog:      &maxBacklog,
+		MinThroughput:   20,
+		MaxFailureRatio: &maxFailureRatio,
+		Metrics:         []benchWorkflowRequestMetricAssertion{{Metric: "historyCpu", Max: &maxCpu}},
+	}
+	assert.NoError(t, a.validate(map[string]bool{"historyCpu": true}))
+
+	summary := benchSummary{
+		Workflows:  1000,
+		Throughput: 25,
+		Latency:    latencyPercentiles{Count: 1000, P99: 420},
+		Statuses:   statusCounts{Completed: 995, Failed: 5},
+	}
+	histogram := []histogramValue{{Backlog: 50, WarmupBacklog: 48, Warmup: true}, {Backlog: 30, WarmupBacklog: 22}}
+	cpu := 1500.0
+	verdict := a.evaluate(summary, histogram, []metricValue{{"historyCpu": &cpu}, {}})
+	assert.True(t, verdict.Passed)
+	assert.Equal(t, 5, len(verdict.Assertions))
+}
+
+func TestAssertionsFail(t *testing.T) {
+	minCpu := 100.0
+	a := benchWorkflowRequestAssertions{
+		MaxLatencyMs:      500,
+		LatencyPercentile: "p50",
+		MinThroughput:     20,
+		Metrics:           []benchWorkflowRequestMetricAssertion{{Metric: "historyCpu", Aggregation: "avg", Min: &minCpu}},
+	}
+	summary := benchSummary{
+		Workflows:  1000,
+		Throughput: 12.5,
+		Latency:    latencyPercentiles{Count: 1000, P50: 600},
+	}
+	verdict := a.evaluate(summary, nil, nil)
+	assert.False(t, verdict.Passed)
+	assert.EqualError(t, verdict.error(), "3 of 3 assertions failed: latency.p50 is 600, expected <= 500; "+
+		"throughput is 12.5, expected >= 20; metrics.historyCpu.avg is no value, expected >= 100 (type: AssertionError, retryable: false)")
+}
+
+func TestAssertionsValidate(t *testing.T) {
+	metrics := map[string]bool{"historyCpu": true}
+	assert.Error(t, (&benchWorkflowRequestAssertions{LatencyPercentile: "p42"}).validate(metrics))
+	max := 1.0
+	assert.Error(t, (&benchWorkflowRequestAssertions{
+		Metrics: []benchWorkflowRequestMetricAssertion{{Metric: "unknown", Max: &max}},
+	}).validate(metrics))
+	assert.Error(t, (&benchWorkflowRequestAssertions{
+		Metrics: []benchWorkflowRequestMetricAssertion{{Metric: "historyCpu"}},
+	}).validate(metrics))
+}
+
+func TestAggregateMetric(t *testing.T) {
+	a, b := 100.0, 300.0
+	values := []metricValue{{"historyCpu": &a}, {}, {"historyCpu": &b}}
+	assert.Equal(t, 300.0, *aggregateMetric(values, "historyCpu", aggregationMax))
+	assert.Equal(t, 200.0, *aggregateMetric(values, "historyCpu", aggregationAvg))
+	assert.Nil(t, aggregateMetric(values, "persistence", aggregationMax))
+}
@@ -0,0 +1,210 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.temporal.io/sdk/workflow"
+)
+
+// signals that control a running bench workflow
+const (
+	pauseSignal  = "pause"
+	resumeSignal = "resume"
+	abortSignal  = "abort"
+	// setRateSignal changes the target rate of the current step, see benchRateChange.
+	setRateSignal = "set_rate"
+)
+
+// controlQuery is the query that driver activities poll to follow the control signals of the bench workflow.
+const controlQuery = "control"
+
+// defaultControlPollInterval is how often the drivers query the control state of the bench workflow, unless
+// the request sets another interval.
+const defaultControlPollInterval = 10 * time.Second
+
+type (
+	// benchControl is the control state of a bench run.
+	benchControl struct {
+		// Paused stops the drivers from starting workflows and holds the next step until the run is resumed.
+		Paused bool
+		// Aborted stops the drivers and skips the remaining steps. The monitor still reports on the started workflows.
+		Aborted bool
+		// DriverRate overrides the rate per second of each driver of the given step, when set.
+		Step       int
+		DriverRate float64
+	}
+
+	// benchRateChange is the input of the set_rate signal. RatePerSecond sets the total rate of the current step,
+	// Factor multiplies its current rate.
+	benchRateChange struct {
+		RatePerSecond float64 `json:"ratePerSecond"`
+		Factor        float64 `json:"factor"`
+	}
+
+	// timelineEvent records a change of the run made by a control signal.
+	timelineEvent struct {
+		ElapsedSeconds float64 `json:"elapsedSeconds"`
+		Step           int     `json:"step"`
+		Event          string  `json:"event"`
+		// RatePerSecond is the total rate of the step after a rate change.
+		RatePerSecond float64 `json:"ratePerSecond,omitempty"`
+	}
+)
+
+// receiveControl handles the control signals and serves the control state to the drivers.
+func (w *benchWorkflow) receiveControl() error {
+	if err := workflow.SetQueryHandler(w.ctx, controlQuery, func() (benchControl, error) {
+		return w.control, nil
+	}); err != nil {
+		return err
+	}
+
+	selector := workflow.NewSelector(w.ctx)
+	selector.AddReceive(workflow.GetSignalChannel(w.ctx, pauseSignal), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(w.ctx, nil)
+		if w.request.Control == nil {
+			w.logger.Warn("ignoring pause, the drivers do not poll the control state without the control of the request")
+			return
+		}
+		if !w.control.Aborted && !w.control.Paused {
+			w.logger.Info("bench run paused")
+			w.control.Paused = true
+			w.recordEvent(pauseSignal, 0)
+		}
+	})
+	selector.AddReceive(workflow.GetSignalChannel(w.ctx, resumeSignal), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(w.ctx, nil)
+		if w.control.Paused {
+			w.logger.Info("bench run resumed")
+			w.control.Paused = false
+			w.recordEvent(resumeSignal, 0)
+		}
+	})
+	selector.AddReceive(workflow.GetSignalChannel(w.ctx, abortSignal), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(w.ctx, nil)
+		if !w.control.Aborted {
+			w.logger.Info("bench run aborted")
+			w.control.Aborted = true
+			w.control.Paused = false
+			w.recordEvent(abortSignal, 0)
+			w.cancelDrivers()
+		}
+	})
+	selector.AddReceive(workflow.GetSignalChannel(w.ctx, setRateSignal), func(c workflow.ReceiveChannel, more bool) {
+		var change benchRateChange
+		c.Receive(w.ctx, &change)
+		w.setRate(change)
+	})
+	workflow.Go(w.ctx, func(ctx workflow.Context) {
+		for {
+			selector.Select(ctx)
+		}
+	})
+	return nil
+}
+
+// awaitResume blocks the next step while the run is paused. It returns false if the run was aborted.
+func (w *benchWorkflow) awaitResume() (bool, error) {
+	if err := workflow.Await(w.ctx, func() bool { return !w.control.Paused || w.control.Aborted }); err != nil {
+		return false, err
+	}
+	return !w.control.Aborted, nil
+}
+
+// setRate applies a rate change to the drivers of the current step.
+func (w *benchWorkflow) setRate(change benchRateChange) {
+	if w.request.Control == nil {
+		w.logger.Warn("ignoring rate change, the drivers do not poll the control state without the control of the request", "change", change)
+		return
+	}
+	if w.phase != phaseDriving || w.control.Aborted {
+		w.logger.Warn("ignoring rate change after the drivers completed", "change", change)
+		return
+	}
+	if w.request.Search != nil {
+		w.logger.Warn("ignoring rate change in search mode", "change", change)
+		return
+	}
+
+	current, err := w.currentRate(workflow.Now(w.ctx))
+	if err != nil {
+		w.logger.Warn("ignoring rate change", "change", change, "Error", err)
+		return
+	}
+	rate := change.RatePerSecond
+	if change.Factor > 0 {
+		rate = current * change.Factor
+	}
+	if rate <= 0 || math.IsInf(rate, 0) {
+		// a factor cannot change the rate of a step that starts workflows as fast as possible
+		w.logger.Warn("ignoring invalid rate change", "change", change, "currentRate", current)
+		return
+	}
+
+	w.logger.Info("changing the rate of the step", "step", w.step, "from", current, "to", rate)
+	w.control.Step = w.step
+	w.control.DriverRate = rate / float64(w.stepConcurrency)
+	w.recordEvent(setRateSignal, rate)
+}
+
+// currentRate returns the total target rate of the current step at the given time, or zero if it is not rate limited.
+// It fails when no step is active.
+func (w *benchWorkflow) currentRate(now time.Time) (float64, error) {
+	if w.step < 0 || w.step >= len(w.request.Steps) {
+		return 0, errors.Errorf("no active step, the current step %d is not one of the %d steps", w.step, len(w.request.Steps))
+	}
+	if w.control.Step == w.step && w.control.DriverRate > 0 {
+		return w.control.DriverRate * float64(w.stepConcurrency), nil
+	}
+	step := w.request.Steps[w.step]
+	if step.FromRatePerSecond > 0 || step.ToRatePerSecond > 0 {
+		total := time.Duration(step.DurationSeconds) * time.Second
+		progress := math.Min(math.Max(float64(now.Sub(w.stepStartTime))/float64(total), 0), 1)
+		return float64(step.FromRatePerSecond) + float64(step.ToRatePerSecond-step.FromRatePerSecond)*progress, nil
+	}
+	return float64(step.RatePerSecond), nil
+}
+
+// controlPollInterval returns how often the drivers poll the control state, or zero if they do not poll it.
+func (w *benchWorkflow) controlPollInterval() time.Duration {
+	switch {
+	case w.request.Control == nil:
+		return 0
+	case w.request.Control.PollIntervalSeconds > 0:
+		return time.Duration(w.request.Control.PollIntervalSeconds) * time.Second
+	}
+	return defaultControlPollInterval
+}
+
+func (w *benchWorkflow) recordEvent(event string, rate float64) {
+	w.timeline = append(w.timeline, timelineEvent{
+		ElapsedSeconds: workflow.Now(w.ctx).Sub(w.startTime).Seconds(),
+		Step:           w.step,
+		Event:          event,
+		RatePerSecond:  rate,
+	})
+}
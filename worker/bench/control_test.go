@@ -0,0 +1,63 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCurrentRateNeedsAnActiveStep(t *testing.T) {
+	w := benchWorkflow{}
+	_, err := w.currentRate(time.Now())
+	assert.Error(t, err)
+
+	w.request.Steps = []benchWorkflowRequestStep{{RatePerSecond: 10}}
+	w.step = 1
+	_, err = w.currentRate(time.Now())
+	assert.Error(t, err)
+}
+
+func TestCurrentRate(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := benchWorkflow{stepStartTime: start, stepConcurrency: 2}
+	w.request.Steps = []benchWorkflowRequestStep{
+		{RatePerSecond: 10},
+		{DurationSeconds: 100, FromRatePerSecond: 10, ToRatePerSecond: 30},
+	}
+
+	rate, err := w.currentRate(start)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, rate)
+
+	w.step = 1
+	rate, err = w.currentRate(start.Add(25 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 15.0, rate)
+
+	w.control = benchControl{Step: 1, DriverRate: 20}
+	rate, err = w.currentRate(start)
+	assert.NoError(t, err)
+	assert.Equal(t, 40.0, rate)
+}
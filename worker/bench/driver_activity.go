@@ -26,46 +26,112 @@ import (
 	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
 	"golang.org/x/time/rate"
+	"hash/fnv"
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-func (a *Activities) DriverActivity(ctx context.Context, request benchDriverActivityRequest) error {
+func (a *Activities) DriverActivity(ctx context.Context, request benchDriverActivityRequest) (benchDriverActivityResult, error) {
 	logger := activity.GetLogger(ctx)
 	driver := benchDriver{
 		ctx:     ctx,
 		logger:  logger,
 		client:  a.temporalClient,
 		request: request,
+		latency: newStartLatencyRecorder(request.ReportStartTime, request.ReportInterval),
 	}
 	return driver.run()
 }
 
 type (
 	benchDriverActivityRequest struct {
-		WorkflowName  string
-		TaskQueueName string
-		BaseID        string
-		BatchSize     int
-		Rate          int
-		Parameters    interface{}
+		// Workflows is the weighted mix of workflows that the driver samples from for each start.
+		Workflows []benchWorkflowRequestWorkflow
+		// Step and Driver identify the driver in the progress that it signals to the bench workflow.
+		Step      int
+		Driver    int
+		BaseID    string
+		BatchSize int
+		// Rate is the share of the step rate of this driver in workflows per second, a fraction when the step
+		// rate is below the number of drivers.
+		Rate     float64
+		StopTime time.Time
+		// RampFromRate and RampToRate define a linear change of the rate between StartTime and StopTime.
+		RampFromRate float64
+		RampToRate   float64
+		StartTime    time.Time
+		// Arrival is the arrival process of an open-loop driver, empty for a rate-limited driver.
+		Arrival  string
+		BurstOn  time.Duration
+		BurstOff time.Duration
+		// MaxInFlight is the maximum number of concurrent starts of the driver. Rate-limited drivers default to
+		// a single start at a time, open-loop drivers are unbounded by default.
+		MaxInFlight int
+		// ReportStartTime and ReportInterval define the report intervals of the start latencies.
+		ReportStartTime time.Time
+		ReportInterval  time.Duration
+		// ProgressInterval is how often the driver signals its progress to the bench workflow, and
+		// MaxProgressSignals is the number of signals after which it stops, which bounds the history of the bench
+		// workflow. The final progress is part of the activity result either way.
+		ProgressInterval   time.Duration
+		MaxProgressSignals int
+		// ControlPollInterval is how often the driver polls the control state of the bench workflow to follow the
+		// pauses and rate changes of the run. Zero when the run does not enable control.
+		ControlPollInterval time.Duration
+		// SearchAttributes and Memo are set on every started workflow. TagIteration adds the iteration
+		// to the search attributes, and it is always added to a memo.
+		SearchAttributes map[string]interface{}
+		Memo             map[string]interface{}
+		TagIteration     bool
+	}
+	benchDriverActivityResult struct {
+		// Started is the number of workflows that the driver started successfully, including previous attempts.
+		Started int
+		// StartLatency holds the start latencies per report interval. Starts made by a previous attempt of the
+		// activity are not included.
+		StartLatency []startLatencyValue
 	}
 	benchDriver struct {
 		ctx     context.Context
 		logger  log.Logger
 		client  client.Client
 		request benchDriverActivityRequest
+		latency *startLatencyRecorder
+		// started is the number of workflows started so far, including the ones of previous attempts.
+		started int64
+		// control is the latest control state polled from the bench workflow, guarded by mu.
+		mu      sync.Mutex
+		control benchControl
 	}
 )
 
 const defaultWorkflowTaskStartToCloseTimeoutDuration = 10 * time.Second
 
-func (d *benchDriver) run() error {
+// rampUpdateInterval is how often a ramping driver adjusts the limit of its rate limiter.
+const rampUpdateInterval = 100 * time.Millisecond
+
+// driverHeartbeatTimeout is the heartbeat timeout of the driver activities. The SDK throttles the heartbeats to
+// a fraction of it, which bounds the time it takes for the cancellation of an aborted run to reach a driver.
+const driverHeartbeatTimeout = 30 * time.Second
+
+// driverHeartbeatInterval is how often a driver heartbeats while it waits for its next start, so that long gaps
+// between starts, e.g. the off periods of a bursty arrival, do not time out the activity.
+const driverHeartbeatInterval = 10 * time.Second
+
+// run starts workflows until the batch is complete or the stop time is reached, and returns the number
+// of workflows started by this driver. A zero batch size means that only the stop time limits the driver.
+// When the bench workflow aborts the run, it cancels the activity and the driver returns a canceled error
+// with its result as details.
+func (d *benchDriver) run() (benchDriverActivityResult, error) {
 	idx := 0
-	deadline := activity.GetInfo(d.ctx).Deadline.Add(-2 * time.Second)
 	if activity.HasHeartbeatDetails(d.ctx) {
 		// we are retrying from an activity timeout, and there is reported progress that we should resume from.
 		var completedIdx int
@@ -75,54 +141,600 @@ func (d *benchDriver) run() error {
 		}
 	}
 
-	limit := rate.Inf
-	if d.request.Rate > 0 {
-		limit = rate.Every(time.Second / time.Duration(d.request.Rate))
+	d.started = int64(idx)
+	stop := make(chan struct{})
+	defer close(stop)
+	go d.reportProgress(stop)
+	if d.request.ControlPollInterval > 0 {
+		go d.watchControl(stop)
+	}
+
+	runStartTime := time.Now()
+	var err error
+	if d.request.Arrival != "" {
+		err = d.runOpenLoop(idx)
+	} else {
+		err = d.runRateLimited(idx)
+	}
+	// only the starts that succeeded count, so that the monitor does not wait for workflows that do not exist
+	started := int(atomic.LoadInt64(&d.started))
+	result := benchDriverActivityResult{Started: started, StartLatency: d.latency.snapshot()}
+	if d.isAborted() {
+		// the starts in flight fail with the canceled context, which is expected when aborting
+		d.logger.Info("driver aborted", "basedID", d.request.BaseID, "started", started)
+		return result, temporal.NewCanceledError(result)
+	}
+	if err != nil {
+		return result, err
 	}
-	limiter := rate.NewLimiter(limit, 1)
-	for i := idx; i < d.request.BatchSize; i++ {
-		if err := limiter.Wait(d.ctx); err != nil {
-			return errors.Wrapf(err, "waiting for limiter")
+
+	elapsed := time.Since(runStartTime)
+	d.logger.Info("driver completed", "basedID", d.request.BaseID, "started", started,
+		"achievedRate", float64(started-idx)/elapsed.Seconds())
+	return result, nil
+}
+
+// runRateLimited paces the starts with a rate limiter. By default, each start completes before the next one begins;
+// a larger MaxInFlight pipelines the starts so that the round-trip latency does not cap the rate.
+func (d *benchDriver) runRateLimited(idx int) error {
+	deadline := activity.GetInfo(d.ctx).Deadline.Add(-2 * time.Second)
+
+	limiter := rate.NewLimiter(d.limitAt(time.Now()), 1)
+
+	waitCtx := d.ctx
+	if !d.request.StopTime.IsZero() {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(d.ctx, d.request.StopTime)
+		defer cancel()
+	}
+
+	maxInFlight := 1
+	if d.request.MaxInFlight > 0 {
+		maxInFlight = d.request.MaxInFlight
+	}
+	tracker := newStartTracker(idx, maxInFlight)
+
+	// the planned start times follow the ideal schedule of the target rate, regardless of how the limiter keeps up.
+	planned := time.Now()
+
+	i := idx
+	for ; d.hasMore(i); i++ {
+		if d.isStopTimeReached() {
+			break
 		}
 
-		if err := d.execute(i); err != nil {
-			d.logger.Error("driver failed to execute", "Error", err, "ID", i)
-			return err
+		running, paused := d.awaitRunning(tracker)
+		if !running {
+			d.stop(tracker)
+			break
+		}
+		// the rate of the step may be changed while the driver runs
+		unlimited := d.limitAt(time.Now()) == rate.Inf
+		if paused && !unlimited {
+			// the starts missed during the pause are skipped rather than made up for
+			planned = time.Now()
 		}
 
-		activity.RecordHeartbeat(d.ctx, i)
+		if err := d.wait(waitCtx, limiter, tracker); err != nil {
+			if d.isAborted() || d.ctx.Err() == nil && !d.request.StopTime.IsZero() {
+				// the run was aborted, or the next start would not happen before the stop time
+				break
+			}
+			tracker.fail(errors.Wrapf(err, "waiting for limiter"))
+			break
+		}
+
+		if tracker.failed() {
+			break
+		}
+		if !tracker.acquire(d.ctx) {
+			d.stop(tracker)
+			break
+		}
+		if unlimited {
+			planned = time.Now()
+		}
+		d.start(tracker, i, planned)
+		if !unlimited {
+			planned, _ = d.nextStart(constantArrival{}, planned)
+		}
 
 		if time.Now().After(deadline) {
-			return &TestError{
+			i++
+			tracker.fail(&TestError{
 				Message: fmt.Sprintf("Timed out driving bench test activity. Progress: %v out of %v",
 					i, d.request.BatchSize),
-			}
+			})
+			break
+		}
+	}
+
+	return tracker.wait()
+}
+
+// runOpenLoop plans the starts on the timeline of the arrival process and fires each of them at its planned time,
+// without waiting for the previous starts to complete.
+func (d *benchDriver) runOpenLoop(idx int) error {
+	deadline := activity.GetInfo(d.ctx).Deadline.Add(-2 * time.Second)
+	planned := time.Now()
+	arrival, err := newArrivalProcess(d.request, planned)
+	if err != nil {
+		return &TestError{Message: err.Error()}
+	}
+
+	tracker := newStartTracker(idx, d.request.MaxInFlight)
+	i := idx
+	for ; d.hasMore(i); i++ {
+		running, paused := d.awaitRunning(tracker)
+		if !running {
+			d.stop(tracker)
+			break
+		}
+		if paused {
+			// the starts missed during the pause are skipped rather than made up for
+			planned = time.Now()
+		}
+
+		var ok bool
+		if planned, ok = d.nextStart(arrival, planned); !ok {
+			break
+		}
+		if planned.After(deadline) {
+			tracker.fail(&TestError{
+				Message: fmt.Sprintf("Timed out driving bench test activity. Progress: %v out of %v",
+					i, d.request.BatchSize),
+			})
+			break
+		}
+
+		if !d.sleepUntil(planned, tracker) {
+			d.stop(tracker)
+			break
+		}
+		if tracker.failed() {
+			break
+		}
+
+		if !tracker.acquire(d.ctx) {
+			d.stop(tracker)
+			break
 		}
+		d.start(tracker, i, planned)
+	}
+
+	return tracker.wait()
+}
 
+// start executes a single start in the background and reports the progress once it completes.
+func (d *benchDriver) start(tracker *startTracker, iterationID int, planned time.Time) {
+	go func() {
+		err := d.execute(iterationID, planned)
+		if err != nil {
+			d.logger.Error("driver failed to execute", "Error", err, "ID", iterationID)
+		}
+		if err == nil {
+			atomic.AddInt64(&d.started, 1)
+		}
+		if progress, ok := tracker.done(iterationID, err); ok {
+			activity.RecordHeartbeat(d.ctx, progress)
+		}
+	}()
+}
+
+// reportProgress signals the progress of the driver to the bench workflow once per progress interval until stopped
+// or out of signals. The final progress is part of the activity result.
+func (d *benchDriver) reportProgress(stop <-chan struct{}) {
+	if d.request.ProgressInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(d.request.ProgressInterval)
+	defer ticker.Stop()
+	info := activity.GetInfo(d.ctx)
+	var sent []int
+	for signals := 0; signals < d.request.MaxProgressSignals; {
 		select {
+		case <-stop:
+			return
 		case <-d.ctx.Done():
-			return fmt.Errorf("driver activity context finished: %+v", d.ctx.Err())
-		default:
+			return
+		case <-ticker.C:
+		}
+
+		counts := d.latency.counts()
+		first, changed := changedIntervals(sent, counts)
+		if !changed {
+			continue
+		}
+		progress := benchDriverProgress{
+			Step:          d.request.Step,
+			Driver:        d.request.Driver,
+			Started:       int(atomic.LoadInt64(&d.started)),
+			FirstInterval: first,
+			Intervals:     counts[first:],
 		}
+		err := d.client.SignalWorkflow(d.ctx, info.WorkflowExecution.ID, info.WorkflowExecution.RunID, progressSignal, progress)
+		if err != nil {
+			// the next signal includes the intervals that this one did not deliver
+			d.logger.Warn("failed to report progress", "Error", err)
+			continue
+		}
+		sent = counts
+		signals++
 	}
+}
 
-	return nil
+// watchControl polls the control state of the bench workflow until stopped. The abort of the run does not depend
+// on it, it cancels the activity.
+func (d *benchDriver) watchControl(stop <-chan struct{}) {
+	ticker := time.NewTicker(d.request.ControlPollInterval)
+	defer ticker.Stop()
+	info := activity.GetInfo(d.ctx)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		value, err := d.client.QueryWorkflow(d.ctx, info.WorkflowExecution.ID, info.WorkflowExecution.RunID, controlQuery)
+		if err != nil {
+			d.logger.Warn("failed to query the control state", "Error", err)
+			continue
+		}
+		var control benchControl
+		if err := value.Get(&control); err != nil {
+			d.logger.Warn("failed to decode the control state", "Error", err)
+			continue
+		}
+
+		d.mu.Lock()
+		d.control = control
+		d.mu.Unlock()
+	}
+}
+
+func (d *benchDriver) currentControl() benchControl {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.control
+}
+
+// isAborted reports whether the bench workflow canceled the activity, which it does when the run is aborted.
+// A timed out activity is not aborted.
+func (d *benchDriver) isAborted() bool {
+	return errors.Is(d.ctx.Err(), context.Canceled)
+}
+
+// awaitRunning blocks while the bench run is paused and keeps heartbeating the progress meanwhile. It returns
+// false if the run was aborted or the activity context finished, and whether the driver was paused.
+func (d *benchDriver) awaitRunning(tracker *startTracker) (running bool, paused bool) {
+	for {
+		if d.ctx.Err() != nil {
+			return false, paused
+		}
+		if !d.currentControl().Paused || d.isStopTimeReached() {
+			return true, paused
+		}
+
+		if !paused {
+			d.logger.Info("driver paused", "basedID", d.request.BaseID)
+			paused = true
+		}
+		d.heartbeat(tracker)
+		select {
+		case <-d.ctx.Done():
+		case <-time.After(d.request.ControlPollInterval):
+		}
+	}
+}
+
+// stop ends the starts of the driver. Unless the run was aborted, the driver stops because its activity
+// context finished, which fails the driver.
+func (d *benchDriver) stop(tracker *startTracker) {
+	if d.isAborted() {
+		return
+	}
+	tracker.fail(fmt.Errorf("driver activity context finished: %+v", d.ctx.Err()))
+}
+
+// nextStart returns the planned time of the start that follows prev, or false if there is no start left before the stop time.
+func (d *benchDriver) nextStart(arrival arrivalProcess, prev time.Time) (time.Time, bool) {
+	r := d.rateAt(prev)
+	for r <= 0 {
+		// nothing to start at a zero rate, e.g. at the beginning of a ramp up from zero.
+		prev = prev.Add(rampUpdateInterval)
+		if d.request.StopTime.IsZero() || !prev.Before(d.request.StopTime) {
+			return prev, false
+		}
+		r = d.rateAt(prev)
+	}
+	next := arrival.next(prev, r)
+	return next, d.request.StopTime.IsZero() || next.Before(d.request.StopTime)
+}
+
+// sleepUntil blocks until the given time, heartbeating meanwhile, and returns false if the run was aborted or
+// the activity context finished before that.
+func (d *benchDriver) sleepUntil(t time.Time, tracker *startTracker) bool {
+	for {
+		delay := time.Until(t)
+		if delay <= 0 {
+			return d.ctx.Err() == nil
+		}
+		if delay > driverHeartbeatInterval {
+			delay = driverHeartbeatInterval
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		d.heartbeat(tracker)
+	}
+}
+
+// heartbeat records the progress of the driver, from which a retry of the activity resumes.
+func (d *benchDriver) heartbeat(tracker *startTracker) {
+	activity.RecordHeartbeat(d.ctx, tracker.progress())
+}
+
+// rateAt returns the target rate per second of this driver at the given time.
+func (d *benchDriver) rateAt(t time.Time) float64 {
+	if r := d.rateOverride(); r > 0 {
+		return r
+	}
+	if d.isRamp() {
+		return float64(d.rampLimit(t))
+	}
+	return d.request.Rate
+}
+
+// limitAt returns the limit of the rate limiter of this driver at the given time.
+func (d *benchDriver) limitAt(t time.Time) rate.Limit {
+	switch r := d.rateOverride(); {
+	case r > 0:
+		return rate.Limit(r)
+	case d.isRamp():
+		return d.rampLimit(t)
+	case d.request.Rate > 0:
+		return rate.Limit(d.request.Rate)
+	}
+	return rate.Inf
+}
+
+// rateOverride returns the rate per second of this driver set with a rate change of the bench workflow, or zero.
+func (d *benchDriver) rateOverride() float64 {
+	control := d.currentControl()
+	if control.Step != d.request.Step {
+		return 0
+	}
+	return control.DriverRate
+}
+
+// wait blocks until the limiter allows the next start, heartbeating meanwhile. Ramping drivers and drivers with
+// a changed rate keep adjusting the limit while they wait.
+func (d *benchDriver) wait(ctx context.Context, limiter *rate.Limiter, tracker *startTracker) error {
+	slice := driverHeartbeatInterval
+	if d.isRamp() || d.rateOverride() > 0 {
+		slice = rampUpdateInterval
+	}
+
+	for {
+		limiter.SetLimit(d.limitAt(time.Now()))
+		sliceCtx, cancel := context.WithTimeout(ctx, slice)
+		err := limiter.Wait(sliceCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || d.isStopTimeReached() {
+			return err
+		}
+
+		// the next start is further away than the slice at the current rate, re-evaluate it later.
+		d.heartbeat(tracker)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(slice):
+		}
+	}
+}
+
+func (d *benchDriver) isRamp() bool {
+	return !d.request.StartTime.IsZero() && !d.request.StopTime.IsZero() &&
+		(d.request.RampFromRate > 0 || d.request.RampToRate > 0)
+}
+
+// rampLimit interpolates the rate of a ramping driver at the given time.
+func (d *benchDriver) rampLimit(now time.Time) rate.Limit {
+	total := d.request.StopTime.Sub(d.request.StartTime)
+	progress := 1.0
+	if total > 0 {
+		progress = math.Min(math.Max(float64(now.Sub(d.request.StartTime))/float64(total), 0), 1)
+	}
+	return rate.Limit(d.request.RampFromRate + (d.request.RampToRate-d.request.RampFromRate)*progress)
+}
+
+// hasMore reports whether the driver has more workflows to start after i. A driver without a batch size
+// is limited by its stop time only, and a driver with neither starts nothing.
+func (d *benchDriver) hasMore(i int) bool {
+	if d.request.BatchSize > 0 {
+		return i < d.request.BatchSize
+	}
+	return !d.request.StopTime.IsZero()
+}
+
+func (d *benchDriver) isStopTimeReached() bool {
+	return !d.request.StopTime.IsZero() && !time.Now().Before(d.request.StopTime)
 }
 
-func (d *benchDriver) execute(iterationID int) error {
-	d.logger.Info("driver.execute starting", "workflowName", d.request.WorkflowName, "basedID", d.request.BaseID, "iterationID", iterationID)
-	workflowID := fmt.Sprintf("%s-%s-%d", d.request.WorkflowName, d.request.BaseID, iterationID)
+// execute starts a single workflow and records its start latency against the planned start time.
+func (d *benchDriver) execute(iterationID int, planned time.Time) error {
+	wf := d.pickWorkflow(iterationID)
+	d.logger.Info("driver.execute starting", "workflowName", wf.Name, "basedID", d.request.BaseID, "iterationID", iterationID)
+	workflowID := fmt.Sprintf("%s-%s-%d", wf.key(), d.request.BaseID, iterationID)
+	searchAttributes, memo := d.tags(iterationID)
 	startOptions := client.StartWorkflowOptions{
 		ID:                       workflowID,
-		TaskQueue:                d.request.TaskQueueName,
+		TaskQueue:                wf.TaskQueue,
 		WorkflowExecutionTimeout: 30 * time.Minute,
 		WorkflowTaskTimeout:      defaultWorkflowTaskStartToCloseTimeoutDuration,
+		SearchAttributes:         searchAttributes,
+		Memo:                     memo,
+	}
+	actual := time.Now()
+	_, err := d.client.ExecuteWorkflow(d.ctx, startOptions, wf.Name, buildPayload(wf.Args))
+	latency := time.Since(actual)
+	if _, ok := err.(*serviceerror.WorkflowExecutionAlreadyStarted); ok {
+		// started by a previous attempt of this activity which did not get to report its progress.
+		d.logger.Info("workflow already started", "ID", workflowID)
+		return nil
 	}
-	_, err := d.client.ExecuteWorkflow(d.ctx, startOptions, d.request.WorkflowName, buildPayload(d.request.Parameters))
 	if err != nil {
 		d.logger.Error("failed to start workflow", "Error", err, "ID", workflowID)
+		return err
+	}
+
+	d.latency.record(planned, actual, latency)
+	d.logger.Info("driver.execute completed", "workflowName", wf.Name, "basedID", d.request.BaseID, "iterationID", iterationID,
+		"plannedTime", planned, "actualTime", actual, "latency", latency)
+	return nil
+}
+
+// tags returns the search attributes and the memo of a single start.
+func (d *benchDriver) tags(iterationID int) (map[string]interface{}, map[string]interface{}) {
+	var searchAttributes, memo map[string]interface{}
+	if d.request.SearchAttributes != nil || d.request.TagIteration {
+		searchAttributes = make(map[string]interface{}, len(d.request.SearchAttributes)+1)
+		for name, value := range d.request.SearchAttributes {
+			searchAttributes[name] = value
+		}
+		if d.request.TagIteration {
+			searchAttributes[iterationSearchAttribute] = iterationID
+		}
+	}
+	if d.request.Memo != nil {
+		memo = make(map[string]interface{}, len(d.request.Memo)+1)
+		for name, value := range d.request.Memo {
+			memo[name] = value
+		}
+		memo["iteration"] = iterationID
+	}
+	return searchAttributes, memo
+}
+
+// pickWorkflow samples the workflow of an iteration from the weighted mix. The choice only depends on the iteration,
+// so that a retried activity starts the same workflow for the same iteration.
+func (d *benchDriver) pickWorkflow(iterationID int) benchWorkflowRequestWorkflow {
+	if len(d.request.Workflows) == 1 {
+		return d.request.Workflows[0]
+	}
+
+	total := 0
+	for _, wf := range d.request.Workflows {
+		total += wf.weight()
+	}
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s-%d", d.request.BaseID, iterationID)
+	r := int(h.Sum64() % uint64(total))
+	for _, wf := range d.request.Workflows {
+		r -= wf.weight()
+		if r < 0 {
+			return wf
+		}
+	}
+	return d.request.Workflows[len(d.request.Workflows)-1]
+}
+
+// startTracker keeps track of asynchronous workflow starts, which may complete out of order.
+type startTracker struct {
+	wg        sync.WaitGroup
+	slots     chan struct{}
+	mu        sync.Mutex
+	next      int
+	completed map[int]bool
+	err       error
+}
+
+// newStartTracker creates a tracker that resumes from the given iteration and bounds the number of starts in flight.
+// A non-positive maxInFlight leaves the number of starts in flight unbounded.
+func newStartTracker(idx int, maxInFlight int) *startTracker {
+	t := &startTracker{next: idx, completed: map[int]bool{}}
+	if maxInFlight > 0 {
+		t.slots = make(chan struct{}, maxInFlight)
 	}
+	return t
+}
+
+// acquire blocks until there is room for another start in flight. It returns false if the context finished first.
+func (t *startTracker) acquire(ctx context.Context) bool {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	t.wg.Add(1)
+	return true
+}
+
+// done marks a start as completed. It returns the highest iteration that completed together with all iterations
+// before it, and whether that progress moved forward and should be reported.
+func (t *startTracker) done(iterationID int, err error) (int, bool) {
+	defer t.wg.Done()
+	if t.slots != nil {
+		defer func() { <-t.slots }()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		if t.err == nil {
+			t.err = err
+		}
+		return t.next - 1, false
+	}
+
+	t.completed[iterationID] = true
+	advanced := false
+	for t.completed[t.next] {
+		delete(t.completed, t.next)
+		t.next++
+		advanced = true
+	}
+	return t.next - 1, advanced
+}
+
+// progress returns the highest iteration that completed together with all iterations before it.
+func (t *startTracker) progress() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.next - 1
+}
+
+func (t *startTracker) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *startTracker) failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err != nil
+}
 
-	d.logger.Info("driver.execute completed", "workflowName", d.request.WorkflowName, "basedID", d.request.BaseID, "iterationID", iterationID)
-	return err
+// wait blocks until all starts completed and returns the first error.
+func (t *startTracker) wait() error {
+	t.wg.Wait()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
 }
@@ -0,0 +1,231 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"golang.org/x/time/rate"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRampLimitInterpolatesLinearly(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := benchDriver{request: benchDriverActivityRequest{
+		StartTime:    start,
+		StopTime:     start.Add(100 * time.Second),
+		RampFromRate: 10,
+		RampToRate:   30,
+	}}
+	assert.True(t, d.isRamp())
+	assert.Equal(t, rate.Limit(10), d.rampLimit(start.Add(-time.Second)))
+	assert.Equal(t, rate.Limit(10), d.rampLimit(start))
+	assert.Equal(t, rate.Limit(20), d.rampLimit(start.Add(50*time.Second)))
+	assert.Equal(t, rate.Limit(30), d.rampLimit(start.Add(100*time.Second)))
+	assert.Equal(t, rate.Limit(30), d.rampLimit(start.Add(200*time.Second)))
+}
+
+func TestRampLimitDown(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := benchDriver{request: benchDriverActivityRequest{
+		StartTime:    start,
+		StopTime:     start.Add(10 * time.Second),
+		RampFromRate: 5,
+		RampToRate:   0,
+	}}
+	assert.Equal(t, rate.Limit(2.5), d.rampLimit(start.Add(5*time.Second)))
+	assert.Equal(t, rate.Limit(0), d.rampLimit(start.Add(10*time.Second)))
+}
+
+func TestFlatStepIsNotRamp(t *testing.T) {
+	d := benchDriver{request: benchDriverActivityRequest{Rate: 10, StopTime: time.Now()}}
+	assert.False(t, d.isRamp())
+}
+
+func TestFractionalDriverRate(t *testing.T) {
+	// a step rate of 5 per second over 10 drivers
+	d := benchDriver{request: benchDriverActivityRequest{Rate: 0.5}}
+	assert.Equal(t, rate.Limit(0.5), d.limitAt(time.Now()))
+	assert.Equal(t, 0.5, d.rateAt(time.Now()))
+}
+
+func TestStartTrackerOutOfOrder(t *testing.T) {
+	tracker := newStartTracker(5, 0)
+	for i := 0; i < 3; i++ {
+		assert.True(t, tracker.acquire(context.Background()))
+	}
+	_, ok := tracker.done(6, nil)
+	assert.False(t, ok)
+	progress, ok := tracker.done(5, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 6, progress)
+	progress, ok = tracker.done(7, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 7, progress)
+	assert.NoError(t, tracker.wait())
+}
+
+func TestStartTrackerKeepsFirstError(t *testing.T) {
+	tracker := newStartTracker(0, 0)
+	tracker.acquire(context.Background())
+	tracker.acquire(context.Background())
+	progress, ok := tracker.done(1, assert.AnError)
+	assert.False(t, ok)
+	assert.Equal(t, -1, progress)
+	tracker.done(0, nil)
+	assert.True(t, tracker.failed())
+	assert.Equal(t, assert.AnError, tracker.wait())
+}
+
+func TestStartTrackerBoundsInFlight(t *testing.T) {
+	tracker := newStartTracker(0, 2)
+	assert.True(t, tracker.acquire(context.Background()))
+	assert.True(t, tracker.acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, tracker.acquire(ctx))
+
+	tracker.done(1, nil)
+	assert.True(t, tracker.acquire(context.Background()))
+	tracker.done(0, nil)
+	progress, ok := tracker.done(2, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 2, progress)
+	assert.NoError(t, tracker.wait())
+}
+
+func TestPickWorkflowFollowsWeights(t *testing.T) {
+	d := benchDriver{request: benchDriverActivityRequest{
+		BaseID: "bench-0-0",
+		Workflows: []benchWorkflowRequestWorkflow{
+			{Name: "light", Weight: 3},
+			{Name: "heavy"},
+		},
+	}}
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[d.pickWorkflow(i).Name]++
+	}
+	assert.InDelta(t, 7500, counts["light"], 300)
+	assert.InDelta(t, 2500, counts["heavy"], 300)
+	assert.Equal(t, d.pickWorkflow(42), d.pickWorkflow(42))
+}
+
+func TestStopFailsUnlessAborted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	d := benchDriver{ctx: ctx}
+	tracker := newStartTracker(0, 1)
+	d.stop(tracker)
+	assert.True(t, tracker.failed())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	d = benchDriver{ctx: ctx}
+	tracker = newStartTracker(0, 1)
+	d.stop(tracker)
+	assert.False(t, tracker.failed())
+}
+
+func TestRateOverrideAppliesToItsStep(t *testing.T) {
+	d := benchDriver{request: benchDriverActivityRequest{Step: 1, Rate: 10}}
+	assert.Equal(t, rate.Limit(10), d.limitAt(time.Now()))
+
+	d.control = benchControl{Step: 0, DriverRate: 25}
+	assert.Equal(t, rate.Limit(10), d.limitAt(time.Now()))
+	assert.Equal(t, 10.0, d.rateAt(time.Now()))
+
+	d.control = benchControl{Step: 1, DriverRate: 25}
+	assert.Equal(t, rate.Limit(25), d.limitAt(time.Now()))
+	assert.Equal(t, 25.0, d.rateAt(time.Now()))
+}
+
+func TestDriverBatchSizeSpreadsTheRemainder(t *testing.T) {
+	total := 0
+	var sizes []int
+	for i := 0; i < 4; i++ {
+		size := driverBatchSize(10, 4, i)
+		sizes = append(sizes, size)
+		total += size
+	}
+	assert.Equal(t, []int{3, 3, 2, 2}, sizes)
+	assert.Equal(t, 10, total)
+}
+
+func TestDriverWithoutBatchSizeNeedsStopTime(t *testing.T) {
+	counted := &benchDriver{request: benchDriverActivityRequest{BatchSize: 2}}
+	assert.True(t, counted.hasMore(1))
+	assert.False(t, counted.hasMore(2))
+
+	timed := &benchDriver{request: benchDriverActivityRequest{StopTime: time.Now().Add(time.Minute)}}
+	assert.True(t, timed.hasMore(1000))
+
+	assert.False(t, (&benchDriver{}).hasMore(0))
+}
+
+// abortingClient starts the first workflows, then aborts the run and fails the start in flight with the
+// canceled context, like a driver canceled by the abort of the bench workflow.
+type abortingClient struct {
+	client.Client
+	succeed int64
+	calls   int64
+	abort   context.CancelFunc
+}
+
+func (c *abortingClient) ExecuteWorkflow(ctx context.Context, _ client.StartWorkflowOptions, _ interface{}, _ ...interface{}) (client.WorkflowRun, error) {
+	if atomic.AddInt64(&c.calls, 1) <= c.succeed {
+		return nil, nil
+	}
+	c.abort()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAbortedDriverReportsTheSucceededStarts(t *testing.T) {
+	ctx, abort := context.WithCancel(context.Background())
+	defer abort()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.SetWorkerOptions(worker.Options{BackgroundActivityContext: ctx})
+	env.RegisterActivityWithOptions(NewActivities(&abortingClient{succeed: 3, abort: abort}, MetricsConfig{}).DriverActivity,
+		activity.RegisterOptions{Name: "bench-DriverActivity"})
+
+	_, err := env.ExecuteActivity("bench-DriverActivity", benchDriverActivityRequest{
+		Workflows: []benchWorkflowRequestWorkflow{{Name: "basic-workflow"}},
+		BaseID:    "run-0-0",
+		BatchSize: 10,
+	})
+	var canceled *temporal.CanceledError
+	assert.ErrorAs(t, err, &canceled)
+	var res benchDriverActivityResult
+	assert.NoError(t, canceled.Details(&res))
+	assert.Equal(t, 3, res.Started)
+}
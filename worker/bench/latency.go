@@ -0,0 +1,155 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// latencyBucketGrowth is the ratio between the upper bounds of two consecutive latency buckets,
+// which keeps the relative error of the reported percentiles within 2%.
+const latencyBucketGrowth = 1.02
+
+type (
+	// latencyHistogram is a compact histogram of durations with logarithmic buckets.
+	// Histograms recorded by different activities can be merged without losing precision.
+	latencyHistogram struct {
+		// Buckets maps the bucket index to the number of recorded durations in that bucket.
+		Buckets map[int]int   `json:"buckets"`
+		Count   int           `json:"count"`
+		Max     time.Duration `json:"max"`
+	}
+
+	// latencyPercentiles is a summary of a latency histogram in milliseconds.
+	latencyPercentiles struct {
+		Count int     `json:"count"`
+		P50   float64 `json:"p50"`
+		P90   float64 `json:"p90"`
+		P95   float64 `json:"p95"`
+		P99   float64 `json:"p99"`
+		P999  float64 `json:"p999"`
+		Max   float64 `json:"max"`
+	}
+)
+
+func (h *latencyHistogram) record(d time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = map[int]int{}
+	}
+	h.Buckets[latencyBucket(d)]++
+	h.Count++
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+func (h *latencyHistogram) merge(other latencyHistogram) {
+	if h.Buckets == nil {
+		h.Buckets = map[int]int{}
+	}
+	for bucket, count := range other.Buckets {
+		h.Buckets[bucket] += count
+	}
+	h.Count += other.Count
+	if other.Max > h.Max {
+		h.Max = other.Max
+	}
+}
+
+// quantile returns the upper bound of the bucket that contains the given quantile, capped by the maximum.
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	buckets := make([]int, 0, len(h.Buckets))
+	for bucket := range h.Buckets {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+
+	seen := 0
+	for _, bucket := range buckets {
+		seen += h.Buckets[bucket]
+		if seen >= rank {
+			if upper := latencyBucketUpperBound(bucket); upper < h.Max {
+				return upper
+			}
+			break
+		}
+	}
+	return h.Max
+}
+
+func (h *latencyHistogram) percentiles() latencyPercentiles {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return latencyPercentiles{
+		Count: h.Count,
+		P50:   ms(h.quantile(0.5)),
+		P90:   ms(h.quantile(0.9)),
+		P95:   ms(h.quantile(0.95)),
+		P99:   ms(h.quantile(0.99)),
+		P999:  ms(h.quantile(0.999)),
+		Max:   ms(h.Max),
+	}
+}
+
+// latencyBucket returns the index of the bucket of a duration. Bucket 0 holds everything up to a microsecond.
+func latencyBucket(d time.Duration) int {
+	us := float64(d) / float64(time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	return int(math.Ceil(math.Log(us) / math.Log(latencyBucketGrowth)))
+}
+
+func latencyBucketUpperBound(bucket int) time.Duration {
+	return time.Duration(math.Pow(latencyBucketGrowth, float64(bucket)) * float64(time.Microsecond))
+}
+
+// get returns the percentile with the given name, e.g. "p99".
+func (p latencyPercentiles) get(name string) (float64, bool) {
+	switch name {
+	case "p50":
+		return p.P50, true
+	case "p90":
+		return p.P90, true
+	case "p95":
+		return p.P95, true
+	case "p99":
+		return p.P99, true
+	case "p999":
+		return p.P999, true
+	case "max":
+		return p.Max, true
+	}
+	return 0, false
+}
@@ -0,0 +1,89 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramPercentiles(t *testing.T) {
+	h := latencyHistogram{}
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	p := h.percentiles()
+	assert.Equal(t, 1000, p.Count)
+	assert.InEpsilon(t, 500, p.P50, 0.02)
+	assert.InEpsilon(t, 900, p.P90, 0.02)
+	assert.InEpsilon(t, 990, p.P99, 0.02)
+	assert.Equal(t, 1000.0, p.P999)
+	assert.Equal(t, 1000.0, p.Max)
+}
+
+func TestLatencyHistogramMerge(t *testing.T) {
+	a := latencyHistogram{}
+	b := latencyHistogram{}
+	for i := 0; i < 99; i++ {
+		a.record(10 * time.Millisecond)
+	}
+	b.record(time.Second)
+
+	merged := latencyHistogram{}
+	merged.merge(a)
+	merged.merge(b)
+	assert.Equal(t, 100, merged.Count)
+	assert.Equal(t, time.Second, merged.Max)
+	assert.InEpsilon(t, float64(10*time.Millisecond), float64(merged.quantile(0.99)), 0.02)
+	assert.Equal(t, time.Second, merged.quantile(1))
+}
+
+func TestLatencyHistogramEmpty(t *testing.T) {
+	h := latencyHistogram{}
+	assert.Equal(t, time.Duration(0), h.quantile(0.5))
+	assert.Equal(t, latencyPercentiles{}, h.percentiles())
+}
+
+func TestStartLatencyRecorderCorrectsForDelay(t *testing.T) {
+	origin := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newStartLatencyRecorder(origin, 10*time.Second)
+	// on schedule in the first interval, one second behind in the second one
+	r.record(origin.Add(time.Second), origin.Add(time.Second), 20*time.Millisecond)
+	r.record(origin.Add(12*time.Second), origin.Add(13*time.Second), 20*time.Millisecond)
+
+	values := r.snapshot()
+	assert.Len(t, values, 2)
+	first := values[0].report()
+	assert.Equal(t, 1, first.Started)
+	assert.Equal(t, 0.0, first.Delay.Max)
+	assert.Equal(t, 20.0, first.Corrected.Max)
+	second := values[1].report()
+	assert.Equal(t, 20.0, second.Latency.Max)
+	assert.Equal(t, 1000.0, second.Delay.Max)
+	assert.Equal(t, 1020.0, second.Corrected.Max)
+
+	total := totalStartLatency(mergeStartLatency(nil, values))
+	assert.Equal(t, 2, total.Started)
+	assert.Equal(t, 1020.0, total.Corrected.Max)
+}
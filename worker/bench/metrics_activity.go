@@ -0,0 +1,48 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+type benchMetricsActivityRequest struct {
+	Metrics   []benchWorkflowRequestMetric
+	StartTime time.Time
+	EndTime   time.Time
+	// Interval is the resolution of the returned values.
+	Interval time.Duration
+}
+
+// MetricsActivity queries the metrics source for the cluster metrics of a bench run.
+func (a *Activities) MetricsActivity(ctx context.Context, request benchMetricsActivityRequest) ([]metricValue, error) {
+	activity.GetLogger(ctx).Info("collecting metrics", "startTime", request.StartTime, "endTime", request.EndTime)
+	source, err := newMetricsSource(a.metrics)
+	if err != nil {
+		return nil, err
+	}
+	return collectMetrics(ctx, source, request.Metrics, request.StartTime, request.EndTime, request.Interval)
+}
@@ -0,0 +1,66 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// snapshotMetrics collects the Prometheus metrics of the run with the metrics activity. A failure to collect them
+// does not fail the run, it is recorded in the result instead.
+func (w *benchWorkflow) snapshotMetrics(res benchMonitorActivityResult) {
+	ctx := workflow.WithActivityOptions(w.ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 2 * time.Minute,
+		TaskQueue:           benchTaskQueue,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	})
+	endTime := w.startTime.Add(w.reportInterval() * time.Duration(len(res.Histogram)))
+	err := workflow.ExecuteActivity(ctx, "bench-MetricsActivity", benchMetricsActivityRequest{
+		Metrics:   w.request.Metrics,
+		StartTime: w.startTime,
+		EndTime:   endTime,
+		Interval:  w.reportInterval(),
+	}).Get(w.ctx, &w.metrics)
+	if err != nil {
+		w.logger.Warn("failed to collect the metrics", "Error", err)
+		w.metricsError = err.Error()
+	}
+}
+
+// metricsSnapshot returns the metrics collected after the monitor completed, so that the queries neither depend on
+// the availability nor on the retention of the metrics source.
+func (w *benchWorkflow) metricsSnapshot() ([]metricValue, error) {
+	if _, err := w.report(); err != nil {
+		return nil, err
+	}
+	if w.metricsError != "" {
+		return nil, errors.Errorf("the metrics could not be collected: %s", w.metricsError)
+	}
+	return w.metrics, nil
+}
@@ -0,0 +1,77 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	metricsSourcePrometheus = "prometheus"
+	metricsSourceOTLPFile   = "otlp-file"
+)
+
+// MetricsConfig selects and configures the backend that serves the cluster metrics of the bench runs.
+type MetricsConfig struct {
+	// Source is the kind of the backend, "prometheus" (default) or "otlp-file".
+	Source string
+	// Prometheus configures the "prometheus" source.
+	Prometheus PrometheusConfig
+	// OTLPFile is the path of the file read by the "otlp-file" source.
+	OTLPFile string
+}
+
+// metricsSource serves the values of the metric series of a bench run.
+type metricsSource interface {
+	// queryRange returns the samples of the series at every interval between the given times. It fails when
+	// the series matches more than one series of the source, which the report could not tell apart.
+	queryRange(ctx context.Context, series metricSeries, startTime, endTime time.Time, interval time.Duration) ([]metricSample, error)
+}
+
+// metricSample is the value of a metric series at a point in time.
+type metricSample struct {
+	time  time.Time
+	value float64
+}
+
+// multipleSeriesError is the error of a query without aggregation that matches more than one series.
+func multipleSeriesError(query string, count int) error {
+	return errors.Errorf("query %q matched %d series, set the aggregation of the metric to combine them", query, count)
+}
+
+func newMetricsSource(config MetricsConfig) (metricsSource, error) {
+	switch config.Source {
+	case "", metricsSourcePrometheus:
+		return newPrometheusSource(config.Prometheus)
+	case metricsSourceOTLPFile:
+		if config.OTLPFile == "" {
+			return nil, errors.New("otlp-file metrics source requires a file")
+		}
+		return &otlpFileSource{path: config.OTLPFile}, nil
+	default:
+		return nil, errors.Errorf("unknown metrics source %q", config.Source)
+	}
+}
@@ -0,0 +1,335 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// lookbackDelta is how far back a selector looks for the latest sample of a series, as in Prometheus.
+const lookbackDelta = 5 * time.Minute
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	matcherPattern    = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(!=|=)\s*("[^"]*"|'[^']*')\s*(,|$)`)
+)
+
+// metricsStore holds metric samples in memory and evaluates the metric series of a bench run over them,
+// for the sources that don't have a query engine of their own. The series follow the Prometheus conventions:
+// counters and gauges are single series, histograms are cumulative _bucket series with an "le" label.
+type metricsStore struct {
+	series map[string]*storedSeries
+}
+
+type storedSeries struct {
+	name    string
+	labels  map[string]string
+	samples []metricSample
+}
+
+// selectorExpr is a metric selector with equality matchers, optionally wrapped in rate().
+type selectorExpr struct {
+	name     string
+	matchers []labelMatcher
+	// rate is the window of rate(), or zero for a plain selector.
+	rate time.Duration
+}
+
+type labelMatcher struct {
+	name   string
+	value  string
+	negate bool
+}
+
+// histogramBucket is the cumulative count of a histogram bucket.
+type histogramBucket struct {
+	upperBound float64
+	count      float64
+}
+
+func newMetricsStore() *metricsStore {
+	return &metricsStore{series: map[string]*storedSeries{}}
+}
+
+// add appends a sample to the series with the given name and labels. The value of a delta sample
+// is added to the previous value of the series.
+func (s *metricsStore) add(name string, labels map[string]string, t time.Time, value float64, delta bool) {
+	key := seriesKey(name, labels)
+	series, ok := s.series[key]
+	if !ok {
+		series = &storedSeries{name: name, labels: labels}
+		s.series[key] = series
+	}
+	if delta && len(series.samples) > 0 {
+		value += series.samples[len(series.samples)-1].value
+	}
+	series.samples = append(series.samples, metricSample{time: t, value: value})
+}
+
+// sort orders the samples of every series by time. It must be called after all samples are added.
+func (s *metricsStore) sort() {
+	for _, series := range s.series {
+		sort.SliceStable(series.samples, func(i, j int) bool {
+			return series.samples[i].time.Before(series.samples[j].time)
+		})
+	}
+}
+
+// evaluate returns the values of the metric series at every interval between the given times.
+func (s *metricsStore) evaluate(series metricSeries, startTime, endTime time.Time, interval time.Duration) ([]metricSample, error) {
+	expr, err := parseSelectorExpr(series.selector)
+	if err != nil {
+		return nil, err
+	}
+	if series.quantile > 0 && expr.rate > 0 {
+		return nil, errors.Errorf("query %q of a histogram metric must be a plain selector", series.selector)
+	}
+	matched := s.match(expr)
+
+	var samples []metricSample
+	for t := startTime; !t.After(endTime); t = t.Add(interval) {
+		if series.quantile > 0 {
+			buckets := map[string][]float64{}
+			for _, m := range matched {
+				if value, ok := m.rate(t, series.window); ok {
+					le := m.labels[model.BucketLabel]
+					buckets[le] = append(buckets[le], value)
+				}
+			}
+			var histogram []histogramBucket
+			for le, values := range buckets {
+				upperBound, err := strconv.ParseFloat(le, 64)
+				if err != nil {
+					continue
+				}
+				histogram = append(histogram, histogramBucket{upperBound: upperBound, count: aggregate(series.aggregation, values)})
+			}
+			samples = append(samples, metricSample{time: t, value: histogramQuantile(series.quantile, histogram)})
+			continue
+		}
+
+		var values []float64
+		for _, m := range matched {
+			value, ok := m.valueAt(t, expr.rate)
+			if !ok {
+				continue
+			}
+			if series.aggregation == "" {
+				samples = append(samples, metricSample{time: t, value: value})
+			}
+			values = append(values, value)
+		}
+		if series.aggregation == "" && len(values) > 1 {
+			return nil, multipleSeriesError(series.selector, len(values))
+		}
+		if series.aggregation != "" && len(values) > 0 {
+			samples = append(samples, metricSample{time: t, value: aggregate(series.aggregation, values)})
+		}
+	}
+	return samples, nil
+}
+
+func (s *metricsStore) match(expr selectorExpr) []*storedSeries {
+	keys := make([]string, 0, len(s.series))
+	for key := range s.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var matched []*storedSeries
+	for _, key := range keys {
+		series := s.series[key]
+		if series.name != expr.name {
+			continue
+		}
+		ok := true
+		for _, m := range expr.matchers {
+			if (series.labels[m.name] == m.value) == m.negate {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, series)
+		}
+	}
+	return matched
+}
+
+// valueAt returns the latest value of the series at the given time, or its rate over the window before it.
+func (s *storedSeries) valueAt(t time.Time, rate time.Duration) (float64, bool) {
+	if rate > 0 {
+		return s.rate(t, rate)
+	}
+	i := sort.Search(len(s.samples), func(i int) bool { return s.samples[i].time.After(t) })
+	if i == 0 || t.Sub(s.samples[i-1].time) >= lookbackDelta {
+		return 0, false
+	}
+	return s.samples[i-1].value, true
+}
+
+// rate returns the per-second increase of the counter series over the window before the given time,
+// accounting for counter resets. Unlike Prometheus, it does not extrapolate to the edges of the window.
+func (s *storedSeries) rate(t time.Time, window time.Duration) (float64, bool) {
+	from := sort.Search(len(s.samples), func(i int) bool { return s.samples[i].time.After(t.Add(-window)) })
+	to := sort.Search(len(s.samples), func(i int) bool { return s.samples[i].time.After(t) })
+	if to-from < 2 {
+		return 0, false
+	}
+	increase := 0.0
+	for i := from + 1; i < to; i++ {
+		if s.samples[i].value < s.samples[i-1].value {
+			increase += s.samples[i].value
+		} else {
+			increase += s.samples[i].value - s.samples[i-1].value
+		}
+	}
+	elapsed := s.samples[to-1].time.Sub(s.samples[from].time).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return increase / elapsed, true
+}
+
+// parseSelectorExpr parses the subset of PromQL that the store evaluates: a metric selector
+// with = and != matchers, optionally wrapped in rate().
+func parseSelectorExpr(query string) (selectorExpr, error) {
+	var expr selectorExpr
+	unsupported := errors.Errorf("unsupported query %q, expected a metric selector, optionally wrapped in rate()", query)
+
+	q := strings.TrimSpace(query)
+	if strings.HasPrefix(q, "rate(") && strings.HasSuffix(q, ")") {
+		inner := strings.TrimSpace(q[len("rate(") : len(q)-1])
+		open := strings.LastIndex(inner, "[")
+		if open < 0 || !strings.HasSuffix(inner, "]") {
+			return expr, unsupported
+		}
+		window, err := model.ParseDuration(inner[open+1 : len(inner)-1])
+		if err != nil || window <= 0 {
+			return expr, unsupported
+		}
+		expr.rate = time.Duration(window)
+		q = strings.TrimSpace(inner[:open])
+	}
+
+	expr.name = q
+	if i := strings.Index(q, "{"); i >= 0 {
+		if !strings.HasSuffix(q, "}") {
+			return expr, unsupported
+		}
+		expr.name = strings.TrimSpace(q[:i])
+		rest := q[i+1 : len(q)-1]
+		for strings.TrimSpace(rest) != "" {
+			m := matcherPattern.FindStringSubmatch(rest)
+			if m == nil {
+				return expr, unsupported
+			}
+			expr.matchers = append(expr.matchers, labelMatcher{name: m[1], value: m[3][1 : len(m[3])-1], negate: m[2] == "!="})
+			rest = rest[len(m[0]):]
+		}
+	}
+	if !metricNamePattern.MatchString(expr.name) {
+		return expr, unsupported
+	}
+	return expr, nil
+}
+
+// histogramQuantile estimates the quantile from the cumulative histogram buckets the way
+// the histogram_quantile function of Prometheus does, by linear interpolation within a bucket.
+func histogramQuantile(q float64, buckets []histogramBucket) float64 {
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].upperBound < buckets[j].upperBound })
+	n := len(buckets)
+	if n < 2 || !math.IsInf(buckets[n-1].upperBound, 1) {
+		return math.NaN()
+	}
+	for i := 1; i < n; i++ {
+		if buckets[i].count < buckets[i-1].count {
+			buckets[i].count = buckets[i-1].count
+		}
+	}
+	total := buckets[n-1].count
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * total
+	b := sort.Search(n-1, func(i int) bool { return buckets[i].count >= rank })
+	if b == n-1 {
+		return buckets[n-2].upperBound
+	}
+	if b == 0 && buckets[0].upperBound <= 0 {
+		return buckets[0].upperBound
+	}
+	start, end, count := 0.0, buckets[b].upperBound, buckets[b].count
+	if b > 0 {
+		start = buckets[b-1].upperBound
+		count -= buckets[b-1].count
+		rank -= buckets[b-1].count
+	}
+	return start + (end-start)*(rank/count)
+}
+
+// aggregate combines the values of several series with the given aggregation, "sum" by default.
+func aggregate(aggregation string, values []float64) float64 {
+	result := values[0]
+	for _, v := range values[1:] {
+		switch aggregation {
+		case "min":
+			result = math.Min(result, v)
+		case "max":
+			result = math.Max(result, v)
+		default:
+			result += v
+		}
+	}
+	if aggregation == "avg" {
+		result /= float64(len(values))
+	}
+	return result
+}
+
+// seriesKey identifies the series with the given name and labels.
+func seriesKey(name string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, label := range names {
+		b.WriteString(",")
+		b.WriteString(label)
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(labels[label]))
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseSelectorExpr(t *testing.T) {
+	expr, err := parseSelectorExpr("rate(container_cpu_usage_seconds_total{container=\"temporal-history\", pod!='x'}[2m])")
+	assert.NoError(t, err)
+	assert.Equal(t, selectorExpr{
+		name: "container_cpu_usage_seconds_total",
+		matchers: []labelMatcher{
+			{name: "container", value: "temporal-history"},
+			{name: "pod", value: "x", negate: true},
+		},
+		rate: 2 * time.Minute,
+	}, expr)
+
+	expr, err = parseSelectorExpr("persistence_latency_bucket")
+	assert.NoError(t, err)
+	assert.Equal(t, selectorExpr{name: "persistence_latency_bucket"}, expr)
+
+	_, err = parseSelectorExpr("sum(pg_stat_activity_count)")
+	assert.Error(t, err)
+	_, err = parseSelectorExpr("up{job=~\"temporal.*\"}")
+	assert.Error(t, err)
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := []histogramBucket{
+		{upperBound: math.Inf(1), count: 100},
+		{upperBound: 0.1, count: 100},
+		{upperBound: 0.01, count: 50},
+	}
+	assert.InDelta(t, 0.01, histogramQuantile(0.5, buckets), 1e-9)
+	assert.InDelta(t, 0.082, histogramQuantile(0.9, buckets), 1e-9)
+	assert.InDelta(t, 0.1, histogramQuantile(1, buckets), 1e-9)
+	assert.True(t, math.IsNaN(histogramQuantile(0.5, []histogramBucket{{upperBound: math.Inf(1)}})))
+}
+
+func TestMetricsStoreRate(t *testing.T) {
+	store := newMetricsStore()
+	start := time.Unix(1000, 0)
+	for i, value := range []float64{0, 10, 20, 5} {
+		store.add("requests_total", map[string]string{}, start.Add(time.Duration(i)*10*time.Second), value, false)
+	}
+	store.sort()
+
+	samples, err := store.evaluate(metricSeries{selector: "rate(requests_total[20s])"}, start, start.Add(30*time.Second), 10*time.Second)
+	assert.NoError(t, err)
+	// the counter resets before the last sample
+	assert.Equal(t, []metricSample{
+		{time: start.Add(10 * time.Second), value: 1},
+		{time: start.Add(20 * time.Second), value: 1},
+		{time: start.Add(30 * time.Second), value: 0.5},
+	}, samples)
+}
+
+func TestMetricsStoreFailsOnMultipleSeriesWithoutAggregation(t *testing.T) {
+	store := newMetricsStore()
+	start := time.Unix(1000, 0)
+	store.add("memory_bytes", map[string]string{"pod": "history-0"}, start, 10, false)
+	store.add("memory_bytes", map[string]string{"pod": "history-1"}, start, 30, false)
+	store.sort()
+
+	_, err := store.evaluate(metricSeries{selector: "memory_bytes"}, start, start, 10*time.Second)
+	assert.Error(t, err)
+
+	samples, err := store.evaluate(metricSeries{selector: "memory_bytes", aggregation: "sum"}, start, start, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []metricSample{{time: start, value: 40}}, samples)
+
+	samples, err = store.evaluate(metricSeries{selector: "memory_bytes{pod='history-1'}"}, start, start, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []metricSample{{time: start, value: 30}}, samples)
+}
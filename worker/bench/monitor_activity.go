@@ -25,7 +25,9 @@ package bench
 import (
 	"context"
 	"fmt"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/filter/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
@@ -34,7 +36,7 @@ import (
 	"time"
 )
 
-func (a *Activities) MonitorActivity(ctx context.Context, request benchMonitorActivityRequest) ([]histogramValue, error) {
+func (a *Activities) MonitorActivity(ctx context.Context, request benchMonitorActivityRequest) (benchMonitorActivityResult, error) {
 	logger := activity.GetLogger(ctx)
 
 	m := benchMonitor{
@@ -49,15 +51,67 @@ func (a *Activities) MonitorActivity(ctx context.Context, request benchMonitorAc
 type (
 	benchMonitorActivityRequest struct {
 		BaseID            string
-		WorkflowName      string
+		Workflows         []benchMonitorWorkflow
 		Count             int
 		StartTime         time.Time
 		IntervalInSeconds int
+		// WarmupEndTime marks the workflows started before it as warm-up, in addition to the tagged warm-up steps.
+		WarmupEndTime time.Time
+		// Query is the advanced visibility query that selects the workflows of the run. When empty, the monitor
+		// lists the workflows by type and start time with the standard visibility APIs.
+		Query string
+	}
+	// benchMonitorWorkflow is an entry of the workflow mix, the monitor lists the workflows by Name and breaks them
+	// down by Alias, which prefixes their IDs.
+	benchMonitorWorkflow struct {
+		Name  string
+		Alias string
+	}
+	benchMonitorActivityResult struct {
+		// Histogram is the histogram of all workflows of the run.
+		Histogram []histogramValue
+		// Workflows breaks the histogram down per alias of the mix, aligned with the intervals of Histogram.
+		Workflows map[string][]histogramValue
+		// Latency holds the start-to-close latencies of the workflows started in each interval of Histogram.
+		Latency []latencyPercentiles
+		// Summary holds the statistics of the whole run, excluding the warm-up.
+		Summary benchSummary
+	}
+	benchSummary struct {
+		// Workflows is the number of measured workflows.
+		Workflows int `json:"workflows"`
+		// WarmupWorkflows is the number of warm-up workflows, which are left out of the other statistics.
+		WarmupWorkflows int `json:"warmupWorkflows"`
+		// DurationSeconds is the time from the first start to the last close of the measured workflows.
+		DurationSeconds float64 `json:"durationSeconds"`
+		// Throughput is the number of measured workflows completed per second over the duration. The workflows that
+		// closed with another status do not count.
+		Throughput float64 `json:"throughput"`
+		// Latency is the start-to-close latency of the measured workflows.
+		Latency latencyPercentiles `json:"latency"`
+		// Statuses breaks the measured workflows down by their close status.
+		Statuses statusCounts `json:"statuses"`
+		// LatencyByWorkflow breaks the start-to-close latency down per alias of the mix.
+		LatencyByWorkflow map[string]latencyPercentiles `json:"latencyByWorkflow"`
+		// StartLatency is the summary of the start latencies reported by the drivers.
+		StartLatency *startLatencyReport `json:"startLatency,omitempty"`
+		// Aborted is set when the run was aborted before all steps completed.
+		Aborted bool `json:"aborted,omitempty"`
+		// Timeline lists the pauses, resumptions, rate changes and the abort of the run.
+		Timeline []timelineEvent `json:"timeline,omitempty"`
+		// Search is the report of the search mode.
+		Search *searchReport `json:"search,omitempty"`
+		// Verdict is the outcome of the assertions of the request.
+		Verdict *benchVerdict `json:"verdict,omitempty"`
 	}
 	workflowTiming struct {
+		// WorkflowName is the alias of the entry of the mix that started the workflow.
+		WorkflowName  string
 		StartTime     time.Time
 		ExecutionTime time.Time
 		CloseTime     time.Time
+		Status        enums.WorkflowExecutionStatus
+		Warmup        bool
 	}
 
 	benchMonitor struct {
@@ -68,19 +122,30 @@ type (
 	}
 )
 
-func (m *benchMonitor) run() ([]histogramValue, error) {
+func (m *benchMonitor) run() (benchMonitorActivityResult, error) {
 	startTime := activity.GetInfo(m.ctx).StartedTime
 	deadline := activity.GetInfo(m.ctx).Deadline.Add(time.Second * -5)
 
 	stats, err := m.validateScenarioCompletion(deadline)
 	if err != nil {
-		return nil, err
+		return benchMonitorActivityResult{}, err
 	}
 
-	hist := m.calculateHistogram(stats)
+	res := benchMonitorActivityResult{Workflows: map[string][]histogramValue{}}
+	origin, count := m.histogramRange(stats)
+	res.Histogram = m.calculateHistogram(stats, origin, count)
+	byWorkflow := map[string][]workflowTiming{}
+	for _, s := range stats {
+		byWorkflow[s.WorkflowName] = append(byWorkflow[s.WorkflowName], s)
+	}
+	for _, wf := range m.request.Workflows {
+		res.Workflows[wf.Alias] = m.calculateHistogram(byWorkflow[wf.Alias], origin, count)
+	}
+	res.Latency = m.calculateLatency(stats, origin, count)
+	res.Summary = m.calculateSummary(stats)
 
 	m.logger.Info("!!! BENCH TEST COMPLETED !!!", "duration", time.Now().Sub(startTime))
-	return hist, nil
+	return res, nil
 }
 
 func (m *benchMonitor) validateScenarioCompletion(deadline time.Time) ([]workflowTiming, error) {
@@ -132,32 +197,77 @@ func (m *benchMonitor) validateScenarioCompletion(deadline time.Time) ([]workflo
 }
 
 func (m *benchMonitor) isComplete() (bool, error) {
+	if m.request.Query != "" {
+		return m.isCompleteAdvanced()
+	}
+
 	m.logger.Info("IsComplete? enter")
 	filterStartTime := m.request.StartTime.Add(-10 * time.Second)
-	ws, err := m.client.ListOpenWorkflow(m.ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{
-		MaximumPageSize: 1,
-		Filters: &workflowservice.ListOpenWorkflowExecutionsRequest_TypeFilter{
-			TypeFilter: &filter.WorkflowTypeFilter{
-				Name: m.request.WorkflowName,
+	for _, workflowName := range m.workflowNames() {
+		ws, err := m.client.ListOpenWorkflow(m.ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{
+			MaximumPageSize: 1,
+			Filters: &workflowservice.ListOpenWorkflowExecutionsRequest_TypeFilter{
+				TypeFilter: &filter.WorkflowTypeFilter{
+					Name: workflowName,
+				},
+			},
+			StartTimeFilter: &filter.StartTimeFilter{
+				EarliestTime: &filterStartTime,
 			},
-		},
-		StartTimeFilter: &filter.StartTimeFilter{
-			EarliestTime: &filterStartTime,
-		},
+		})
+		if err != nil {
+			m.logger.Info("IsComplete? exit", "error", err)
+			return false, err
+		}
+		if len(ws.Executions) > 0 {
+			m.logger.Info("IsComplete? false", "workflowName", workflowName)
+			return false, nil
+		}
+	}
+	m.logger.Info("IsComplete? true")
+	return true, nil
+}
+
+// isCompleteAdvanced counts the running workflows of the run with an advanced visibility query.
+func (m *benchMonitor) isCompleteAdvanced() (bool, error) {
+	m.logger.Info("IsComplete? enter")
+	res, err := m.client.CountWorkflow(m.ctx, &workflowservice.CountWorkflowExecutionsRequest{
+		Query: fmt.Sprintf("%s AND ExecutionStatus = 'Running'", m.request.Query),
 	})
 	if err != nil {
 		m.logger.Info("IsComplete? exit", "error", err)
 		return false, err
 	}
-	done := len(ws.Executions) == 0
-	m.logger.Info(fmt.Sprintf("IsComplete? %t", done))
+	done := res.Count == 0
+	m.logger.Info(fmt.Sprintf("IsComplete? %t", done), "running", res.Count)
 	return done, nil
 }
 
 func (m *benchMonitor) collectWorkflowTimings() []workflowTiming {
+	if m.request.Query != "" {
+		stats, err := m.collectWorkflowTimingsAdvanced()
+		if err != nil {
+			m.logger.Info("Stats? exit", "error", err)
+			return nil
+		}
+		return stats
+	}
+
+	var stats []workflowTiming
+	for _, workflowName := range m.workflowNames() {
+		timings, err := m.collectWorkflowTimingsOf(workflowName, len(stats))
+		if err != nil {
+			m.logger.Info("Stats? exit", "error", err)
+			return nil
+		}
+		stats = append(stats, timings...)
+	}
+	return stats
+}
+
+func (m *benchMonitor) collectWorkflowTimingsOf(workflowName string, collected int) ([]workflowTiming, error) {
 	var stats []workflowTiming
 	filterStartTime := m.request.StartTime.Add(-10 * time.Second)
-	prefix := fmt.Sprintf("%s-%s-", m.request.WorkflowName, m.request.BaseID)
 	var nextPageToken []byte
 	for {
 		ws, err := m.client.ListClosedWorkflow(m.ctx, &workflowservice.ListClosedWorkflowExecutionsRequest{
@@ -167,23 +277,47 @@ func (m *benchMonitor) collectWorkflowTimings() []workflowTiming {
 			},
 			Filters: &workflowservice.ListClosedWorkflowExecutionsRequest_TypeFilter{
 				TypeFilter: &filter.WorkflowTypeFilter{
-					Name: m.request.WorkflowName,
+					Name: workflowName,
 				},
 			},
 			NextPageToken: nextPageToken,
 		})
 		if err != nil {
-			m.logger.Info("Stats? exit", "error", err)
-			return nil
+			return nil, err
 		}
 
 		for _, w := range ws.Executions {
-			if strings.HasPrefix(w.Execution.WorkflowId, prefix) {
-				stats = append(stats, workflowTiming{
-					StartTime:     *w.StartTime,
-					ExecutionTime: *w.ExecutionTime,
-					CloseTime:     *w.CloseTime,
-				})
+			if alias, ok := m.belongsToRun(workflowName, w); ok {
+				stats = append(stats, m.newWorkflowTiming(alias, w))
+			}
+		}
+
+		if len(ws.NextPageToken) == 0 {
+			break
+		}
+		nextPageToken = ws.NextPageToken
+		activity.RecordHeartbeat(m.ctx, collected+len(stats))
+	}
+	return stats, nil
+}
+
+// collectWorkflowTimingsAdvanced lists the closed workflows of the run with an advanced visibility query.
+func (m *benchMonitor) collectWorkflowTimingsAdvanced() ([]workflowTiming, error) {
+	var stats []workflowTiming
+	var nextPageToken []byte
+	for {
+		ws, err := m.client.ListWorkflow(m.ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			PageSize:      1000,
+			Query:         fmt.Sprintf("%s AND ExecutionStatus != 'Running'", m.request.Query),
+			NextPageToken: nextPageToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, w := range ws.Executions {
+			if alias, ok := m.belongsToRun(w.GetType().GetName(), w); ok {
+				stats = append(stats, m.newWorkflowTiming(alias, w))
 			}
 		}
 
@@ -193,10 +327,52 @@ func (m *benchMonitor) collectWorkflowTimings() []workflowTiming {
 		nextPageToken = ws.NextPageToken
 		activity.RecordHeartbeat(m.ctx, len(stats))
 	}
-	return stats
+	return stats, nil
 }
 
-func (m *benchMonitor) calculateHistogram(stats []workflowTiming) []histogramValue {
+// workflowNames returns the distinct workflow types of the mix.
+func (m *benchMonitor) workflowNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, wf := range m.request.Workflows {
+		if !seen[wf.Name] {
+			seen[wf.Name] = true
+			names = append(names, wf.Name)
+		}
+	}
+	return names
+}
+
+// belongsToRun checks that the workflow was started by the drivers of this run and returns the alias of the entry
+// of the mix that started it.
+func (m *benchMonitor) belongsToRun(workflowName string, w *workflowpb.WorkflowExecutionInfo) (string, bool) {
+	for _, wf := range m.request.Workflows {
+		prefix := fmt.Sprintf("%s-%s-", wf.Alias, m.request.BaseID)
+		if wf.Name == workflowName && strings.HasPrefix(w.Execution.WorkflowId, prefix) {
+			return wf.Alias, true
+		}
+	}
+	return "", false
+}
+
+func (m *benchMonitor) newWorkflowTiming(alias string, w *workflowpb.WorkflowExecutionInfo) workflowTiming {
+	warmupPrefix := fmt.Sprintf("%s-%s-%s-", alias, m.request.BaseID, warmupTag)
+	return workflowTiming{
+		WorkflowName:  alias,
+		StartTime:     *w.StartTime,
+		ExecutionTime: *w.ExecutionTime,
+		CloseTime:     *w.CloseTime,
+		Status:        w.Status,
+		Warmup: strings.HasPrefix(w.Execution.WorkflowId, warmupPrefix) ||
+			w.StartTime.Before(m.request.WarmupEndTime),
+	}
+}
+
+// histogramRange returns the start time of the first interval and the number of intervals that cover all workflows.
+func (m *benchMonitor) histogramRange(stats []workflowTiming) (time.Time, int) {
+	if len(stats) == 0 {
+		return time.Time{}, 0
+	}
 	startTime := time.Now().AddDate(0, 0, 1)
 	endTime := time.Now().AddDate(0, 0, -1)
 	for _, s := range stats {
@@ -207,8 +383,14 @@ func (m *benchMonitor) calculateHistogram(stats []workflowTiming) []histogramVal
 			endTime = s.CloseTime
 		}
 	}
+	return startTime, int(endTime.Sub(startTime).Seconds())/m.request.IntervalInSeconds + 1
+}
+
+func (m *benchMonitor) calculateHistogram(stats []workflowTiming, startTime time.Time, count int) []histogramValue {
+	if count == 0 {
+		return nil
+	}
 	interval := m.request.IntervalInSeconds
-	count := int(endTime.Sub(startTime).Seconds())/interval + 1
 	hist := make([]histogramValue, count)
 	for _, s := range stats {
 		si := int(s.StartTime.Sub(startTime).Seconds()) / interval
@@ -217,9 +399,71 @@ func (m *benchMonitor) calculateHistogram(stats []workflowTiming) []histogramVal
 		hist[ei].Execution += 1
 		ci := int(s.CloseTime.Sub(startTime).Seconds()) / interval
 		hist[ci].Closed += 1
+		hist[ci].add(s.Status)
 		for i := si; i < ci; i++ {
 			hist[i].Backlog += 1
+			if s.Warmup {
+				hist[i].WarmupBacklog += 1
+			}
+		}
+		if s.Warmup {
+			hist[si].Warmup = true
 		}
 	}
 	return hist
 }
+
+// calculateLatency returns the start-to-close latency percentiles of the workflows started in each interval.
+func (m *benchMonitor) calculateLatency(stats []workflowTiming, startTime time.Time, count int) []latencyPercentiles {
+	if count == 0 {
+		return nil
+	}
+	interval := m.request.IntervalInSeconds
+	hist := make([]latencyHistogram, count)
+	for _, s := range stats {
+		si := int(s.StartTime.Sub(startTime).Seconds()) / interval
+		hist[si].record(s.CloseTime.Sub(s.StartTime))
+	}
+	res := make([]latencyPercentiles, count)
+	for i := range hist {
+		res[i] = hist[i].percentiles()
+	}
+	return res
+}
+
+func (m *benchMonitor) calculateSummary(stats []workflowTiming) benchSummary {
+	summary := benchSummary{LatencyByWorkflow: map[string]latencyPercentiles{}}
+	var latency latencyHistogram
+	latencyByWorkflow := map[string]*latencyHistogram{}
+	var startTime, endTime time.Time
+	for _, s := range stats {
+		if s.Warmup {
+			summary.WarmupWorkflows++
+			continue
+		}
+		summary.Workflows++
+		summary.Statuses.add(s.Status)
+		latency.record(s.CloseTime.Sub(s.StartTime))
+		if latencyByWorkflow[s.WorkflowName] == nil {
+			latencyByWorkflow[s.WorkflowName] = &latencyHistogram{}
+		}
+		latencyByWorkflow[s.WorkflowName].record(s.CloseTime.Sub(s.StartTime))
+		if startTime.IsZero() || startTime.After(s.StartTime) {
+			startTime = s.StartTime
+		}
+		if endTime.Before(s.CloseTime) {
+			endTime = s.CloseTime
+		}
+	}
+	summary.Latency = latency.percentiles()
+	for name, h := range latencyByWorkflow {
+		summary.LatencyByWorkflow[name] = h.percentiles()
+	}
+	if summary.Workflows > 0 {
+		summary.DurationSeconds = endTime.Sub(startTime).Seconds()
+		if summary.DurationSeconds > 0 {
+			summary.Throughput = float64(summary.Statuses.Completed) / summary.DurationSeconds
+		}
+	}
+	return summary
+}
@@ -0,0 +1,262 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"testing"
+	"time"
+)
+
+var monitorStart = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func executionInfo(workflowID string, start time.Duration) *workflowpb.WorkflowExecutionInfo {
+	startTime := monitorStart.Add(start)
+	closeTime := startTime.Add(time.Second)
+	return &workflowpb.WorkflowExecutionInfo{
+		Execution:     &commonpb.WorkflowExecution{WorkflowId: workflowID},
+		StartTime:     &startTime,
+		ExecutionTime: &startTime,
+		CloseTime:     &closeTime,
+		Status:        enums.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+	}
+}
+
+func TestNewWorkflowTimingTagsWarmup(t *testing.T) {
+	m := benchMonitor{request: benchMonitorActivityRequest{BaseID: "run-0", WarmupEndTime: monitorStart.Add(time.Minute)}}
+	tests := []struct {
+		name       string
+		workflowID string
+		start      time.Duration
+		warmup     bool
+	}{
+		{name: "warm-up step", workflowID: "basic-workflow-run-0-warmup-0-7", start: 5 * time.Minute, warmup: true},
+		{name: "before the warm-up end", workflowID: "basic-workflow-run-0-0-7", start: 30 * time.Second, warmup: true},
+		{name: "at the warm-up end", workflowID: "basic-workflow-run-0-0-7", start: time.Minute, warmup: false},
+		{name: "after the warm-up end", workflowID: "basic-workflow-run-0-0-7", start: 5 * time.Minute, warmup: false},
+		{name: "warm-up tag of another run", workflowID: "basic-workflow-run-1-warmup-0-7", start: 5 * time.Minute, warmup: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timing := m.newWorkflowTiming("basic-workflow", executionInfo(tt.workflowID, tt.start))
+			assert.Equal(t, tt.warmup, timing.Warmup)
+			assert.Equal(t, "basic-workflow", timing.WorkflowName)
+			assert.Equal(t, monitorStart.Add(tt.start), timing.StartTime)
+			assert.Equal(t, enums.WORKFLOW_EXECUTION_STATUS_COMPLETED, timing.Status)
+		})
+	}
+}
+
+func TestBelongsToRunReturnsTheAlias(t *testing.T) {
+	m := benchMonitor{request: benchMonitorActivityRequest{BaseID: "run-0", Workflows: []benchMonitorWorkflow{
+		{Name: "basic-workflow", Alias: "short"},
+		{Name: "basic-workflow", Alias: "long"},
+	}}}
+	tests := []struct {
+		name         string
+		workflowName string
+		workflowID   string
+		alias        string
+		ok           bool
+	}{
+		{name: "first entry", workflowName: "basic-workflow", workflowID: "short-run-0-0-7", alias: "short", ok: true},
+		{name: "second entry", workflowName: "basic-workflow", workflowID: "long-run-0-0-7", alias: "long", ok: true},
+		{name: "another run", workflowName: "basic-workflow", workflowID: "long-run-1-0-7", ok: false},
+		{name: "another type", workflowName: "other-workflow", workflowID: "long-run-0-0-7", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alias, ok := m.belongsToRun(tt.workflowName, executionInfo(tt.workflowID, 0))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.alias, alias)
+		})
+	}
+	assert.Equal(t, []string{"basic-workflow"}, m.workflowNames())
+}
+
+func TestCalculateHistogramBucketBoundaries(t *testing.T) {
+	m := benchMonitor{request: benchMonitorActivityRequest{IntervalInSeconds: 10}}
+	timing := func(start, execution, close time.Duration) workflowTiming {
+		return workflowTiming{
+			StartTime:     monitorStart.Add(start),
+			ExecutionTime: monitorStart.Add(execution),
+			CloseTime:     monitorStart.Add(close),
+			Status:        enums.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+		}
+	}
+	tests := []struct {
+		name   string
+		timing workflowTiming
+		want   []histogramValue
+	}{
+		{
+			name:   "within the first interval",
+			timing: timing(0, time.Second, 9*time.Second),
+			want:   []histogramValue{{Started: 1, Execution: 1, Closed: 1, statusCounts: statusCounts{Completed: 1}}, {}, {}},
+		},
+		{
+			name:   "closed at the start of the next interval",
+			timing: timing(0, 0, 10*time.Second),
+			want: []histogramValue{
+				{Started: 1, Execution: 1, Backlog: 1},
+				{Closed: 1, statusCounts: statusCounts{Completed: 1}},
+				{},
+			},
+		},
+		{
+			name:   "just before the end of an interval",
+			timing: timing(9999*time.Millisecond, 10*time.Second, 29999*time.Millisecond),
+			want: []histogramValue{
+				{Started: 1, Backlog: 1},
+				{Execution: 1, Backlog: 1},
+				{Closed: 1, statusCounts: statusCounts{Completed: 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.calculateHistogram([]workflowTiming{tt.timing}, monitorStart, 3))
+		})
+	}
+}
+
+func TestCalculateHistogramTagsWarmupIntervals(t *testing.T) {
+	m := benchMonitor{request: benchMonitorActivityRequest{IntervalInSeconds: 10}}
+	stats := []workflowTiming{
+		{StartTime: monitorStart, ExecutionTime: monitorStart, CloseTime: monitorStart.Add(time.Second), Warmup: true},
+		{StartTime: monitorStart.Add(15 * time.Second), ExecutionTime: monitorStart.Add(15 * time.Second), CloseTime: monitorStart.Add(16 * time.Second)},
+	}
+	hist := m.calculateHistogram(stats, monitorStart, 2)
+	assert.True(t, hist[0].Warmup)
+	assert.False(t, hist[1].Warmup)
+
+	// a warm-up workflow that is still open after the warm-up is part of the warm-up backlog
+	stats[0].CloseTime = monitorStart.Add(25 * time.Second)
+	hist = m.calculateHistogram(stats, monitorStart, 3)
+	assert.Equal(t, histogramValue{Started: 1, Execution: 1, Backlog: 1, WarmupBacklog: 1, Warmup: true}, hist[0])
+	assert.Equal(t, 1, hist[1].Backlog)
+	assert.Equal(t, 1, hist[1].WarmupBacklog)
+	assert.False(t, hist[1].Warmup)
+	assert.Nil(t, m.calculateHistogram(nil, monitorStart, 0))
+}
+
+func closedTiming(name string, start, latency time.Duration, status enums.WorkflowExecutionStatus) workflowTiming {
+	return workflowTiming{
+		WorkflowName:  name,
+		StartTime:     monitorStart.Add(start),
+		ExecutionTime: monitorStart.Add(start),
+		CloseTime:     monitorStart.Add(start + latency),
+		Status:        status,
+	}
+}
+
+func TestCalculateLatency(t *testing.T) {
+	m := benchMonitor{request: benchMonitorActivityRequest{IntervalInSeconds: 10}}
+	completed := enums.WORKFLOW_EXECUTION_STATUS_COMPLETED
+	single := latencyPercentiles{Count: 1, P50: 1500, P90: 1500, P95: 1500, P99: 1500, P999: 1500, Max: 1500}
+	tests := []struct {
+		name  string
+		stats []workflowTiming
+		count int
+		want  []latencyPercentiles
+	}{
+		{name: "no intervals", count: 0, want: nil},
+		{name: "no workflows", count: 2, want: []latencyPercentiles{{}, {}}},
+		{
+			name:  "single sample",
+			stats: []workflowTiming{closedTiming("basic-workflow", 12*time.Second, 1500*time.Millisecond, completed)},
+			count: 2,
+			want:  []latencyPercentiles{{}, single},
+		},
+		{
+			name: "by start interval",
+			stats: []workflowTiming{
+				closedTiming("basic-workflow", 0, 1500*time.Millisecond, completed),
+				closedTiming("basic-workflow", 9*time.Second, 20*time.Second, completed),
+			},
+			count: 3,
+			want: []latencyPercentiles{
+				{Count: 2, P50: 1500, P90: 20000, P95: 20000, P99: 20000, P999: 20000, Max: 20000},
+				{},
+				{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.calculateLatency(tt.stats, monitorStart, tt.count)
+			assert.Equal(t, len(tt.want), len(got))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Count, got[i].Count)
+				assert.InDelta(t, tt.want[i].P50, got[i].P50, tt.want[i].P50*0.02)
+				assert.InDelta(t, tt.want[i].P99, got[i].P99, tt.want[i].P99*0.02)
+				assert.Equal(t, tt.want[i].Max, got[i].Max)
+			}
+		})
+	}
+}
+
+func TestCalculateSummary(t *testing.T) {
+	m := benchMonitor{}
+	completed := enums.WORKFLOW_EXECUTION_STATUS_COMPLETED
+
+	empty := m.calculateSummary(nil)
+	assert.Equal(t, 0, empty.Workflows)
+	assert.Equal(t, 0.0, empty.DurationSeconds)
+	assert.Equal(t, 0.0, empty.Throughput)
+	assert.Equal(t, latencyPercentiles{}, empty.Latency)
+	assert.Empty(t, empty.LatencyByWorkflow)
+
+	single := m.calculateSummary([]workflowTiming{closedTiming("basic-workflow", 0, 2*time.Second, completed)})
+	assert.Equal(t, 1, single.Workflows)
+	assert.Equal(t, 2.0, single.DurationSeconds)
+	assert.Equal(t, 0.5, single.Throughput)
+	assert.Equal(t, latencyPercentiles{Count: 1, P50: 2000, P90: 2000, P95: 2000, P99: 2000, P999: 2000, Max: 2000}, single.Latency)
+	assert.Equal(t, single.Latency, single.LatencyByWorkflow["basic-workflow"])
+
+	warmup := closedTiming("basic-workflow", 0, time.Minute, completed)
+	warmup.Warmup = true
+	mixed := m.calculateSummary([]workflowTiming{
+		warmup,
+		closedTiming("basic-workflow", 10*time.Second, time.Second, completed),
+		closedTiming("basic-workflow-long", 12*time.Second, 8*time.Second, completed),
+	})
+	assert.Equal(t, 2, mixed.Workflows)
+	assert.Equal(t, 1, mixed.WarmupWorkflows)
+	assert.Equal(t, 10.0, mixed.DurationSeconds)
+	assert.Equal(t, 0.2, mixed.Throughput)
+	assert.Equal(t, 8000.0, mixed.Latency.Max)
+	assert.Equal(t, 1, mixed.LatencyByWorkflow["basic-workflow"].Count)
+	assert.Equal(t, 8000.0, mixed.LatencyByWorkflow["basic-workflow-long"].Max)
+
+	failed := m.calculateSummary([]workflowTiming{
+		closedTiming("basic-workflow", 0, 5*time.Second, completed),
+		closedTiming("basic-workflow", 0, 10*time.Second, enums.WORKFLOW_EXECUTION_STATUS_FAILED),
+		closedTiming("basic-workflow", 0, 10*time.Second, enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT),
+	})
+	assert.Equal(t, 3, failed.Workflows)
+	assert.Equal(t, 0.1, failed.Throughput)
+}
@@ -0,0 +1,240 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// otlpDeltaTemporality is the AGGREGATION_TEMPORALITY_DELTA value of OTLP.
+const otlpDeltaTemporality = 1
+
+// otlpMaxLineSize is the longest line of an OTLP/JSON file, i.e. the largest export request.
+const otlpMaxLineSize = 64 * 1024 * 1024
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+// otlpFileSource serves the metrics from a file with an OTLP/JSON export request per line,
+// the format written by the file exporter of the OpenTelemetry Collector. Histograms are exposed
+// as Prometheus-style _bucket series, and the names of metrics and attributes have the characters
+// that Prometheus doesn't allow replaced with underscores.
+type otlpFileSource struct {
+	path  string
+	store *metricsStore
+}
+
+type otlpExportRequest struct {
+	ResourceMetrics []struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []struct {
+			Metrics []otlpMetric `json:"metrics"`
+		} `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string  `json:"stringValue"`
+		IntValue    *otlpInt `json:"intValue"`
+		DoubleValue *float64 `json:"doubleValue"`
+		BoolValue   *bool    `json:"boolValue"`
+	} `json:"value"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Gauge *struct {
+		DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+	Sum *struct {
+		DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+		AggregationTemporality int                   `json:"aggregationTemporality"`
+	} `json:"sum"`
+	Histogram *struct {
+		DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+		AggregationTemporality int                      `json:"aggregationTemporality"`
+	} `json:"histogram"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes   []otlpAttribute `json:"attributes"`
+	TimeUnixNano otlpInt         `json:"timeUnixNano"`
+	AsDouble     *float64        `json:"asDouble"`
+	AsInt        *otlpInt        `json:"asInt"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes     []otlpAttribute `json:"attributes"`
+	TimeUnixNano   otlpInt         `json:"timeUnixNano"`
+	Count          otlpInt         `json:"count"`
+	Sum            *float64        `json:"sum"`
+	BucketCounts   []otlpInt       `json:"bucketCounts"`
+	ExplicitBounds []float64       `json:"explicitBounds"`
+}
+
+// otlpInt is a 64-bit integer, which OTLP/JSON encodes as a string.
+type otlpInt int64
+
+func (i *otlpInt) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = otlpInt(value)
+	return nil
+}
+
+func (s *otlpFileSource) queryRange(_ context.Context, series metricSeries, startTime, endTime time.Time, interval time.Duration) ([]metricSample, error) {
+	if s.store == nil {
+		store, err := loadOTLPFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		s.store = store
+	}
+	return s.store.evaluate(series, startTime, endTime, interval)
+}
+
+func loadOTLPFile(path string) (*metricsStore, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening OTLP file")
+	}
+	defer file.Close()
+
+	store := newMetricsStore()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, otlpMaxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var request otlpExportRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return nil, errors.Wrapf(err, "parsing line %d of OTLP file", line)
+		}
+		request.addTo(store)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "reading OTLP file")
+	}
+	store.sort()
+	return store, nil
+}
+
+func (r *otlpExportRequest) addTo(store *metricsStore) {
+	for _, resource := range r.ResourceMetrics {
+		for _, scope := range resource.ScopeMetrics {
+			for _, m := range scope.Metrics {
+				name := invalidNameChars.ReplaceAllString(m.Name, "_")
+				switch {
+				case m.Gauge != nil:
+					for _, p := range m.Gauge.DataPoints {
+						store.add(name, otlpLabels(resource.Resource.Attributes, p.Attributes), p.time(), p.value(), false)
+					}
+				case m.Sum != nil:
+					delta := m.Sum.AggregationTemporality == otlpDeltaTemporality
+					for _, p := range m.Sum.DataPoints {
+						store.add(name, otlpLabels(resource.Resource.Attributes, p.Attributes), p.time(), p.value(), delta)
+					}
+				case m.Histogram != nil:
+					delta := m.Histogram.AggregationTemporality == otlpDeltaTemporality
+					for _, p := range m.Histogram.DataPoints {
+						p.addTo(store, name, otlpLabels(resource.Resource.Attributes, p.Attributes), delta)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (p *otlpNumberDataPoint) time() time.Time {
+	return time.Unix(0, int64(p.TimeUnixNano))
+}
+
+func (p *otlpNumberDataPoint) value() float64 {
+	if p.AsInt != nil {
+		return float64(*p.AsInt)
+	}
+	if p.AsDouble != nil {
+		return *p.AsDouble
+	}
+	return 0
+}
+
+// addTo adds the data point to the store as the cumulative _bucket, _count and _sum series of a Prometheus histogram.
+func (p *otlpHistogramDataPoint) addTo(store *metricsStore, name string, labels map[string]string, delta bool) {
+	t := time.Unix(0, int64(p.TimeUnixNano))
+	cumulative := 0.0
+	for i, count := range p.BucketCounts {
+		cumulative += float64(count)
+		le := "+Inf"
+		if i < len(p.ExplicitBounds) {
+			le = strconv.FormatFloat(p.ExplicitBounds[i], 'f', -1, 64)
+		}
+		bucketLabels := map[string]string{model.BucketLabel: le}
+		for k, v := range labels {
+			bucketLabels[k] = v
+		}
+		store.add(name+"_bucket", bucketLabels, t, cumulative, delta)
+	}
+	store.add(name+"_count", labels, t, float64(p.Count), delta)
+	if p.Sum != nil {
+		store.add(name+"_sum", labels, t, *p.Sum, delta)
+	}
+}
+
+// otlpLabels merges the attributes of a resource and of a data point into the labels of a series.
+func otlpLabels(resource, point []otlpAttribute) map[string]string {
+	labels := map[string]string{}
+	for _, attributes := range [][]otlpAttribute{resource, point} {
+		for _, a := range attributes {
+			var value string
+			switch {
+			case a.Value.StringValue != nil:
+				value = *a.Value.StringValue
+			case a.Value.IntValue != nil:
+				value = strconv.FormatInt(int64(*a.Value.IntValue), 10)
+			case a.Value.DoubleValue != nil:
+				value = strconv.FormatFloat(*a.Value.DoubleValue, 'f', -1, 64)
+			case a.Value.BoolValue != nil:
+				value = strconv.FormatBool(*a.Value.BoolValue)
+			}
+			labels[invalidNameChars.ReplaceAllString(a.Key, "_")] = value
+		}
+	}
+	return labels
+}
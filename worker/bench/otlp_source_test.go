@@ -0,0 +1,74 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOTLPFileSource(t *testing.T) {
+	start := time.Unix(1000, 0)
+	var lines []string
+	for i := 0; i < 4; i++ {
+		ts := start.Add(time.Duration(i) * 10 * time.Second).UnixNano()
+		lines = append(lines, fmt.Sprintf(`{"resourceMetrics":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"history"}}]},"scopeMetrics":[{"metrics":[`+
+			`{"name":"persistence_latency","histogram":{"aggregationTemporality":2,"dataPoints":[{"attributes":[{"key":"type","value":{"stringValue":"history"}}],"timeUnixNano":"%d","count":"%d","bucketCounts":["%d","%d","0"],"explicitBounds":[0.01,0.1]}]}},`+
+			`{"name":"container_cpu_usage_seconds_total","sum":{"aggregationTemporality":2,"isMonotonic":true,"dataPoints":[`+
+			`{"attributes":[{"key":"container","value":{"stringValue":"temporal-history"}}],"timeUnixNano":"%d","asDouble":%d},`+
+			`{"attributes":[{"key":"container","value":{"stringValue":"cass-cassandra"}}],"timeUnixNano":"%d","asDouble":%d}]}}`+
+			`]}]}]}`, ts, 10*i, 5*i, 5*i, ts, 5*i, ts, 50*i))
+	}
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644))
+
+	source, err := newMetricsSource(MetricsConfig{Source: metricsSourceOTLPFile, OTLPFile: path})
+	assert.NoError(t, err)
+	metrics := []benchWorkflowRequestMetric{
+		{Name: "persistence", Query: "persistence_latency_bucket{type='history',service_name='history'}", Quantile: 0.9, RateWindowSeconds: 20, Scale: 1000},
+		{Name: "historyCpu", Query: "rate(container_cpu_usage_seconds_total{container=\"temporal-history\"}[20s])", Aggregation: "sum", Scale: 1000},
+	}
+	values, err := collectMetrics(context.Background(), source, metrics, start, start.Add(30*time.Second), 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(values))
+	assert.Equal(t, metricValue{}, values[0])
+	for _, v := range values[1:] {
+		persistence, ok := v.get("persistence")
+		assert.True(t, ok)
+		assert.Equal(t, 82.0, persistence)
+		cpu, ok := v.get("historyCpu")
+		assert.True(t, ok)
+		assert.Equal(t, 500.0, cpu)
+	}
+
+	_, err = newMetricsSource(MetricsConfig{Source: metricsSourceOTLPFile})
+	assert.Error(t, err)
+	_, err = newMetricsSource(MetricsConfig{Source: "graphite"})
+	assert.Error(t, err)
+}
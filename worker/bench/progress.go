@@ -0,0 +1,224 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// progressSignal is the signal that driver activities use to report their progress to the bench workflow.
+const progressSignal = "progress"
+
+const (
+	phaseDriving    = "driving"
+	phaseMonitoring = "monitoring"
+	phaseCompleted  = "completed"
+)
+
+type (
+	// benchDriverProgress is the progress that a driver activity signals to the bench workflow.
+	benchDriverProgress struct {
+		Step   int
+		Driver int
+		// Started is the number of workflows that the driver started, including the ones of previous attempts.
+		Started int
+		// Intervals is the number of workflows started by the current attempt in each report interval, from
+		// FirstInterval on. The driver only sends the intervals that changed since its previous signal.
+		FirstInterval int
+		Intervals     []int
+	}
+
+	// benchProgress is the result of the progress query.
+	benchProgress struct {
+		Phase          string           `json:"phase"`
+		Paused         bool             `json:"paused"`
+		Aborted        bool             `json:"aborted"`
+		Step           int              `json:"step"`
+		Steps          int              `json:"steps"`
+		Started        int              `json:"started"`
+		Drivers        []driverProgress `json:"drivers"`
+		ElapsedSeconds float64          `json:"elapsedSeconds"`
+		// EtaSeconds is the estimated time until the drivers of the last step complete. It is unknown for
+		// count-based steps without a rate that did not start yet.
+		EtaSeconds *float64 `json:"etaSeconds,omitempty"`
+	}
+
+	driverProgress struct {
+		Step      int  `json:"step"`
+		Driver    int  `json:"driver"`
+		Started   int  `json:"started"`
+		Completed bool `json:"completed"`
+		// Intervals is not part of the query result, it feeds the partial histogram.
+		Intervals []int `json:"-"`
+	}
+)
+
+// receiveProgress updates the progress of the drivers with the signals they send while they run.
+func (w *benchWorkflow) receiveProgress() {
+	ch := workflow.GetSignalChannel(w.ctx, progressSignal)
+	workflow.Go(w.ctx, func(ctx workflow.Context) {
+		for {
+			var p benchDriverProgress
+			ch.Receive(ctx, &p)
+			d := w.driverProgress(p.Step, p.Driver)
+			if d.Completed || p.Started < d.Started {
+				// the signal was delayed, the driver already reported more progress
+				continue
+			}
+			d.Started = p.Started
+			d.Intervals = mergeIntervals(d.Intervals, p.FirstInterval, p.Intervals)
+		}
+	})
+}
+
+// mergeIntervals overwrites the intervals from first on with the given ones.
+func mergeIntervals(intervals []int, first int, changed []int) []int {
+	for len(intervals) < first+len(changed) {
+		intervals = append(intervals, 0)
+	}
+	copy(intervals[first:], changed)
+	return intervals
+}
+
+// changedIntervals returns the index of the first interval that differs from the ones sent before, and false if
+// none does.
+func changedIntervals(sent, counts []int) (int, bool) {
+	for i, count := range counts {
+		if i >= len(sent) || sent[i] != count {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// driverProgress returns the progress of a driver, adding it to the tracked drivers when it is not known yet.
+func (w *benchWorkflow) driverProgress(stepIndex, driverIndex int) *driverProgress {
+	for len(w.drivers) <= stepIndex {
+		w.drivers = append(w.drivers, nil)
+	}
+	for len(w.drivers[stepIndex]) <= driverIndex {
+		w.drivers[stepIndex] = append(w.drivers[stepIndex], &driverProgress{Step: stepIndex, Driver: len(w.drivers[stepIndex])})
+	}
+	return w.drivers[stepIndex][driverIndex]
+}
+
+// completeDriver records the final result of a driver activity.
+func (w *benchWorkflow) completeDriver(stepIndex, driverIndex int, res benchDriverActivityResult) {
+	d := w.driverProgress(stepIndex, driverIndex)
+	d.Completed = true
+	if res.Started > d.Started {
+		d.Started = res.Started
+	}
+	if len(res.StartLatency) > 0 {
+		d.Intervals = make([]int, len(res.StartLatency))
+		for i := range res.StartLatency {
+			d.Intervals[i] = res.StartLatency[i].Latency.Count
+		}
+	}
+}
+
+func (w *benchWorkflow) progress() benchProgress {
+	now := workflow.Now(w.ctx)
+	p := benchProgress{
+		Phase:          w.phase,
+		Paused:         w.control.Paused,
+		Aborted:        w.control.Aborted,
+		Step:           w.step,
+		Steps:          len(w.request.Steps),
+		Drivers:        []driverProgress{},
+		ElapsedSeconds: now.Sub(w.startTime).Seconds(),
+	}
+	startedInStep := 0
+	for stepIndex, drivers := range w.drivers {
+		for _, d := range drivers {
+			p.Started += d.Started
+			p.Drivers = append(p.Drivers, *d)
+			if stepIndex == w.step {
+				startedInStep += d.Started
+			}
+		}
+	}
+	if w.phase == phaseDriving && !w.control.Aborted {
+		if eta, ok := estimateRemaining(w.request.Steps, w.step, now.Sub(w.stepStartTime), startedInStep); ok {
+			seconds := eta.Seconds()
+			p.EtaSeconds = &seconds
+		}
+	}
+	return p
+}
+
+// partialHistogram is the histogram of the workflows started so far, as reported by the drivers. The executions
+// and closes are only known once the monitor completes.
+func (w *benchWorkflow) partialHistogram() []histogramValue {
+	values := []histogramValue{}
+	for _, drivers := range w.drivers {
+		for _, d := range drivers {
+			for len(values) < len(d.Intervals) {
+				values = append(values, histogramValue{})
+			}
+			for i, started := range d.Intervals {
+				values[i].Started += started
+			}
+		}
+	}
+	return values
+}
+
+// estimateRemaining estimates the time until the drivers of the last step complete, given the time elapsed in the
+// current step and the number of workflows started in it. Count-based steps are extrapolated from the rate achieved
+// so far, or from their configured rate when they did not start yet.
+func estimateRemaining(steps []benchWorkflowRequestStep, stepIndex int, elapsed time.Duration, started int) (time.Duration, bool) {
+	if stepIndex >= len(steps) {
+		return 0, true
+	}
+
+	var remaining time.Duration
+	step := steps[stepIndex]
+	switch {
+	case step.DurationSeconds > 0:
+		remaining = time.Duration(step.DurationSeconds)*time.Second - elapsed
+	case started > 0:
+		remaining = time.Duration(float64(elapsed) * float64(step.Count-started) / float64(started))
+	case step.RatePerSecond > 0:
+		remaining = time.Duration(step.Count)*time.Second/time.Duration(step.RatePerSecond) - elapsed
+	default:
+		return 0, false
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	for _, next := range steps[stepIndex+1:] {
+		switch {
+		case next.DurationSeconds > 0:
+			remaining += time.Duration(next.DurationSeconds) * time.Second
+		case next.RatePerSecond > 0:
+			remaining += time.Duration(next.Count) * time.Second / time.Duration(next.RatePerSecond)
+		default:
+			return 0, false
+		}
+	}
+	return remaining, true
+}
@@ -0,0 +1,111 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEstimateRemainingOfDurationSteps(t *testing.T) {
+	steps := []benchWorkflowRequestStep{
+		{DurationSeconds: 60, RatePerSecond: 10},
+		{DurationSeconds: 120, RatePerSecond: 20},
+	}
+	eta, ok := estimateRemaining(steps, 0, 20*time.Second, 200)
+	assert.True(t, ok)
+	assert.Equal(t, 160*time.Second, eta)
+}
+
+func TestEstimateRemainingExtrapolatesCountSteps(t *testing.T) {
+	steps := []benchWorkflowRequestStep{
+		{Count: 1000},
+		{Count: 600, RatePerSecond: 20},
+	}
+	eta, ok := estimateRemaining(steps, 0, 10*time.Second, 250)
+	assert.True(t, ok)
+	assert.Equal(t, 60*time.Second, eta)
+}
+
+func TestEstimateRemainingIsUnknownWithoutRate(t *testing.T) {
+	steps := []benchWorkflowRequestStep{
+		{DurationSeconds: 60},
+		{Count: 1000},
+	}
+	_, ok := estimateRemaining(steps, 0, 10*time.Second, 100)
+	assert.False(t, ok)
+
+	_, ok = estimateRemaining(steps, 1, 0, 0)
+	assert.False(t, ok)
+}
+
+func TestPartialHistogramSumsDriverIntervals(t *testing.T) {
+	w := benchWorkflow{}
+	w.driverProgress(0, 1).Intervals = []int{10, 5}
+	w.driverProgress(1, 0).Intervals = []int{0, 3, 7}
+	w.completeDriver(0, 0, benchDriverActivityResult{Started: 4, StartLatency: make([]startLatencyValue, 1)})
+
+	histogram := w.partialHistogram()
+	assert.Equal(t, 3, len(histogram))
+	assert.Equal(t, 10, histogram[0].Started)
+	assert.Equal(t, 8, histogram[1].Started)
+	assert.Equal(t, 7, histogram[2].Started)
+	assert.True(t, w.driverProgress(0, 0).Completed)
+	assert.Equal(t, 4, w.driverProgress(0, 0).Started)
+}
+
+func TestChangedIntervals(t *testing.T) {
+	tests := []struct {
+		name    string
+		sent    []int
+		counts  []int
+		first   int
+		changed bool
+	}{
+		{name: "nothing sent", counts: []int{0, 3}, first: 0, changed: true},
+		{name: "last interval grew", sent: []int{0, 3}, counts: []int{0, 5}, first: 1, changed: true},
+		{name: "new interval", sent: []int{0, 5}, counts: []int{0, 5, 0}, first: 2, changed: true},
+		{name: "unchanged", sent: []int{0, 5}, counts: []int{0, 5}, changed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, changed := changedIntervals(tt.sent, tt.counts)
+			assert.Equal(t, tt.changed, changed)
+			if changed {
+				assert.Equal(t, tt.first, first)
+			}
+		})
+	}
+}
+
+func TestMergeIntervals(t *testing.T) {
+	intervals := mergeIntervals(nil, 0, []int{0, 3})
+	assert.Equal(t, []int{0, 3}, intervals)
+
+	intervals = mergeIntervals(intervals, 1, []int{5, 2})
+	assert.Equal(t, []int{0, 5, 2}, intervals)
+
+	intervals = mergeIntervals(intervals, 4, []int{1})
+	assert.Equal(t, []int{0, 5, 2, 0, 1}, intervals)
+}
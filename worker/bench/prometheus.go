@@ -0,0 +1,387 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// defaultMetrics is the metrics profile of a cluster with Cassandra persistence, used when the request defines no metrics.
+var defaultMetrics = []benchWorkflowRequestMetric{
+	{
+		Name:     "persistence",
+		Title:    "Persistence Latency",
+		Query:    "persistence_latency_bucket{type='history'}",
+		Quantile: 0.95,
+		Unit:     "ms",
+		Scale:    1000,
+	},
+	{
+		Name:     "historyService",
+		Title:    "History Service Latency",
+		Query:    "service_latency_bucket{type='history'}",
+		Quantile: 0.95,
+		Unit:     "ms",
+		Scale:    1000,
+	},
+	{
+		Name:        "persistenceCpu",
+		Title:       "Persistence CPU",
+		Query:       "rate(container_cpu_usage_seconds_total{container=\"cass-cassandra\"}[2m])",
+		Aggregation: "sum",
+		Unit:        "mcores",
+		Scale:       1000,
+	},
+	{
+		Name:        "historyCpu",
+		Title:       "History Service CPU",
+		Query:       "rate(container_cpu_usage_seconds_total{container=\"temporal-history\"}[2m])",
+		Aggregation: "sum",
+		Unit:        "mcores",
+		Scale:       1000,
+	},
+	{
+		Name:        "historyMemory",
+		Title:       "History Service Memory Working Set",
+		Query:       "container_memory_working_set_bytes{container=\"temporal-history\"}",
+		Aggregation: "max",
+		Unit:        "MB",
+		Scale:       1.0 / 1048576,
+	},
+}
+
+// minRateWindow is the shortest default window of the rate of a histogram. It spans a few scrape intervals of
+// Prometheus, so that the rate has enough samples even when the report interval is shorter.
+const minRateWindow = time.Minute
+
+// maxPrecision is the highest number of decimals of the values of a metric.
+const maxPrecision = 6
+
+// quantiles are the quantiles that a histogram metric can report by their names.
+var quantiles = map[string]float64{"p50": 0.5, "p90": 0.9, "p95": 0.95, "p99": 0.99, "p999": 0.999, "max": 1}
+
+// metricSeries is a single value that a metric reports in every interval, e.g. one of the quantiles of a histogram.
+type metricSeries struct {
+	name  string
+	title string
+	// query is the PromQL expression of the series.
+	query string
+	scale float64
+	// precision is the number of decimals of the scaled values.
+	precision int
+	// selector, quantile, aggregation and window define the series for the sources that don't evaluate PromQL.
+	selector    string
+	quantile    float64
+	aggregation string
+	window      time.Duration
+}
+
+func (m *benchWorkflowRequestMetric) validate() error {
+	if m.Name == "" || m.Query == "" {
+		return errors.New("metric must define a name and a query")
+	}
+	if m.Quantile < 0 || m.Quantile > 1 {
+		return errors.Errorf("quantile of metric %q must be between 0 and 1", m.Name)
+	}
+	if m.Quantile > 0 && len(m.Quantiles) > 0 {
+		return errors.Errorf("metric %q must not define both quantile and quantiles", m.Name)
+	}
+	for _, q := range m.Quantiles {
+		if _, ok := quantiles[q]; !ok {
+			return errors.Errorf("unknown quantile %q of metric %q", q, m.Name)
+		}
+	}
+	switch m.Aggregation {
+	case "", "sum", "min", "max", "avg":
+	default:
+		return errors.Errorf("unknown aggregation %q of metric %q", m.Aggregation, m.Name)
+	}
+	if m.Precision < 0 || m.Precision > maxPrecision {
+		return errors.Errorf("precision of metric %q must be between 0 and %d", m.Name, maxPrecision)
+	}
+	return nil
+}
+
+// series returns the values that the metric reports. The rate of a histogram is taken over the report interval,
+// but no shorter than minRateWindow, unless the metric defines its own window.
+func (m *benchWorkflowRequestMetric) series(interval time.Duration) []metricSeries {
+	window := interval
+	if window < minRateWindow {
+		window = minRateWindow
+	}
+	if m.RateWindowSeconds > 0 {
+		window = time.Duration(m.RateWindowSeconds) * time.Second
+	}
+	scale := m.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	newSeries := func(name, title string, quantile float64) metricSeries {
+		aggregation := m.Aggregation
+		if quantile > 0 && aggregation == "" {
+			aggregation = "sum"
+		}
+		return metricSeries{
+			name:        name,
+			title:       title,
+			query:       m.promQL(quantile, window),
+			scale:       scale,
+			precision:   m.Precision,
+			selector:    m.Query,
+			quantile:    quantile,
+			aggregation: aggregation,
+			window:      window,
+		}
+	}
+	if len(m.Quantiles) == 0 {
+		return []metricSeries{newSeries(m.Name, m.title(""), m.Quantile)}
+	}
+
+	series := make([]metricSeries, len(m.Quantiles))
+	for i, q := range m.Quantiles {
+		series[i] = newSeries(m.Name+strings.ToUpper(q[:1])+q[1:], m.title(q), quantiles[q])
+	}
+	return series
+}
+
+// promQL returns the PromQL expression of the metric, or of the given quantile of a histogram metric.
+func (m *benchWorkflowRequestMetric) promQL(quantile float64, window time.Duration) string {
+	if quantile > 0 {
+		aggregation := m.Aggregation
+		if aggregation == "" {
+			aggregation = "sum"
+		}
+		return fmt.Sprintf("histogram_quantile(%g,%s(rate(%s[%ds])) by (le))", quantile, aggregation, m.Query, int(window.Seconds()))
+	}
+	if m.Aggregation != "" {
+		return fmt.Sprintf("%s(%s)", m.Aggregation, m.Query)
+	}
+	return m.Query
+}
+
+// title returns the CSV header of the metric, or of one of its quantiles.
+func (m *benchWorkflowRequestMetric) title(quantile string) string {
+	title := m.Title
+	if title == "" {
+		title = m.Name
+	}
+	if quantile != "" {
+		title = fmt.Sprintf("%s %s", title, quantile)
+	}
+	if m.Unit != "" {
+		title = fmt.Sprintf("%s (%s)", title, m.Unit)
+	}
+	return title
+}
+
+// collectMetrics queries the source for the given metrics between the given times, with a value per interval.
+func collectMetrics(ctx context.Context, source metricsSource, metrics []benchWorkflowRequestMetric, startTime, endTime time.Time, interval time.Duration) ([]metricValue, error) {
+	count := int(endTime.Sub(startTime)/interval) + 1
+	values := make([]metricValue, count)
+	for i := range values {
+		values[i] = metricValue{}
+	}
+	for _, m := range metrics {
+		for _, series := range m.series(interval) {
+			samples, err := source.queryRange(ctx, series, startTime, endTime, interval)
+			if err != nil {
+				return nil, errors.Wrapf(err, "query %s", series.name)
+			}
+
+			for _, sample := range samples {
+				i := int(sample.time.Sub(startTime) / interval)
+				if i < 0 || i >= count || math.IsNaN(sample.value) {
+					continue
+				}
+				value := series.round(sample.value * series.scale)
+				values[i][series.name] = &value
+			}
+		}
+	}
+	return values, nil
+}
+
+// round rounds a scaled value of the series to its precision.
+func (s metricSeries) round(value float64) float64 {
+	p := math.Pow10(s.precision)
+	return math.Round(value*p) / p
+}
+
+// get returns the value of the metric with the given name, or false if the metric has no value in the interval.
+func (v metricValue) get(name string) (float64, bool) {
+	value := v[name]
+	if value == nil {
+		return 0, false
+	}
+	return *value, true
+}
+
+// PrometheusConfig holds the connection settings of the Prometheus-compatible API that serves the cluster metrics.
+type PrometheusConfig struct {
+	// URL is the address of the API, "http://prometheus-server" by default.
+	URL string
+	// BearerToken is sent in the Authorization header of every request.
+	BearerToken string
+	// BearerTokenFile holds the bearer token instead of BearerToken. It is read again every
+	// bearerTokenRefreshInterval, so that a rotated token is picked up.
+	BearerTokenFile string
+	// Username and Password are sent as basic authentication credentials. They can't be combined with a bearer token.
+	Username string
+	Password string
+	// Headers are added to every request, e.g. X-Scope-OrgID to select the tenant of Mimir or Cortex.
+	Headers map[string]string
+	// TLS configures the client side of HTTPS connections. The system defaults are used when nil.
+	TLS *tls.Config
+	// Timeout limits the duration of a single query, 10 seconds by default.
+	Timeout time.Duration
+}
+
+// prometheusSource runs the PromQL queries of the metric series against the configured Prometheus API.
+type prometheusSource struct {
+	api     v1.API
+	timeout time.Duration
+}
+
+func newPrometheusSource(config PrometheusConfig) (*prometheusSource, error) {
+	if config.BearerToken != "" && config.BearerTokenFile != "" {
+		return nil, errors.New("bearer token and bearer token file are mutually exclusive")
+	}
+	if (config.BearerToken != "" || config.BearerTokenFile != "") && config.Username != "" {
+		return nil, errors.New("bearer token and basic authentication are mutually exclusive")
+	}
+	address := config.URL
+	if address == "" {
+		address = "http://prometheus-server"
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config.TLS
+	client, err := api.NewClient(api.Config{
+		Address:      address,
+		RoundTripper: &prometheusRoundTripper{config: config, tokenFile: &bearerTokenFile{path: config.BearerTokenFile}, next: transport},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating API client")
+	}
+	return &prometheusSource{api: v1.NewAPI(client), timeout: timeout}, nil
+}
+
+func (s *prometheusSource) queryRange(ctx context.Context, series metricSeries, startTime, endTime time.Time, interval time.Duration) ([]metricSample, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	result, _, err := s.api.QueryRange(ctx, series.query, v1.Range{
+		Start: startTime,
+		End:   endTime,
+		Step:  interval,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "query %q", series.query)
+	}
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, errors.New("query yielded no results")
+	}
+	if len(matrix) > 1 {
+		return nil, multipleSeriesError(series.query, len(matrix))
+	}
+
+	var samples []metricSample
+	for _, stream := range matrix {
+		for _, pair := range stream.Values {
+			samples = append(samples, metricSample{time: pair.Timestamp.Time(), value: float64(pair.Value)})
+		}
+	}
+	return samples, nil
+}
+
+// bearerTokenRefreshInterval is how long a bearer token read from a file is used before the file is read again.
+const bearerTokenRefreshInterval = time.Minute
+
+// prometheusRoundTripper adds the configured credentials and headers to the requests.
+type prometheusRoundTripper struct {
+	config    PrometheusConfig
+	tokenFile *bearerTokenFile
+	next      http.RoundTripper
+}
+
+func (t *prometheusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.config.BearerToken
+	if t.config.BearerTokenFile != "" {
+		var err error
+		if token, err = t.tokenFile.token(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	req = req.Clone(req.Context())
+	for name, value := range t.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if t.config.Username != "" {
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// bearerTokenFile caches the bearer token read from a file for bearerTokenRefreshInterval.
+type bearerTokenFile struct {
+	path   string
+	mu     sync.Mutex
+	value  string
+	readAt time.Time
+}
+
+// token returns the cached token, reading the file again once the cached token is older than the refresh interval.
+func (f *bearerTokenFile) token(now time.Time) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.readAt.IsZero() && now.Sub(f.readAt) < bearerTokenRefreshInterval {
+		return f.value, nil
+	}
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading bearer token file")
+	}
+	f.value = strings.TrimSpace(string(data))
+	f.readAt = now
+	return f.value, nil
+}
@@ -0,0 +1,180 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMetricSeries(t *testing.T) {
+	histogram := benchWorkflowRequestMetric{Name: "latency", Query: "service_latency_bucket{type='history'}", Quantile: 0.99}
+	assert.Equal(t, []metricSeries{{
+		name:        "latency",
+		title:       "latency",
+		query:       "histogram_quantile(0.99,sum(rate(service_latency_bucket{type='history'}[600s])) by (le))",
+		scale:       1,
+		selector:    "service_latency_bucket{type='history'}",
+		quantile:    0.99,
+		aggregation: "sum",
+		window:      10 * time.Minute,
+	}}, histogram.series(10*time.Minute))
+
+	// a report interval shorter than a few scrapes keeps the minimum window
+	assert.Equal(t, minRateWindow, histogram.series(10 * time.Second)[0].window)
+	assert.Equal(t, 90*time.Second, histogram.series(90 * time.Second)[0].window)
+
+	gauge := benchWorkflowRequestMetric{Name: "memory", Query: "container_memory_working_set_bytes", Aggregation: "max"}
+	assert.Equal(t, "max(container_memory_working_set_bytes)", gauge.series(time.Minute)[0].query)
+
+	raw := benchWorkflowRequestMetric{Name: "connections", Query: "sum(pg_stat_activity_count)"}
+	assert.Equal(t, "sum(pg_stat_activity_count)", raw.series(time.Minute)[0].query)
+}
+
+func TestMetricSeriesOfQuantiles(t *testing.T) {
+	m := benchWorkflowRequestMetric{
+		Name:              "persistence",
+		Title:             "Persistence Latency",
+		Query:             "persistence_latency_bucket",
+		Quantiles:         []string{"p50", "max"},
+		RateWindowSeconds: 120,
+		Unit:              "ms",
+		Scale:             1000,
+	}
+	series := m.series(10 * time.Second)
+	assert.Equal(t, 2, len(series))
+	assert.Equal(t, "persistenceP50", series[0].name)
+	assert.Equal(t, "Persistence Latency p50 (ms)", series[0].title)
+	assert.Equal(t, "histogram_quantile(0.5,sum(rate(persistence_latency_bucket[120s])) by (le))", series[0].query)
+	assert.Equal(t, "persistenceMax", series[1].name)
+	assert.Equal(t, "histogram_quantile(1,sum(rate(persistence_latency_bucket[120s])) by (le))", series[1].query)
+	assert.Equal(t, 1000.0, series[1].scale)
+}
+
+func TestMetricTitle(t *testing.T) {
+	assert.Equal(t, "History Service CPU (mcores)", defaultMetrics[3].title(""))
+	assert.Equal(t, "connections", (&benchWorkflowRequestMetric{Name: "connections"}).title(""))
+}
+
+func TestMetricValidate(t *testing.T) {
+	assert.NoError(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up"}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a"}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up", Quantile: 2}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up", Aggregation: "p99"}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up", Quantiles: []string{"p42"}}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up", Quantile: 0.5, Quantiles: []string{"p99"}}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up", Precision: -1}).validate())
+	assert.Error(t, (&benchWorkflowRequestMetric{Name: "a", Query: "up", Precision: maxPrecision + 1}).validate())
+}
+
+func TestMetricSeriesRoundsToPrecision(t *testing.T) {
+	integer := (&benchWorkflowRequestMetric{Name: "a", Query: "up"}).series(time.Minute)[0]
+	assert.Equal(t, 13.0, integer.round(12.5))
+	assert.Equal(t, 12.0, integer.round(12.345))
+	decimals := (&benchWorkflowRequestMetric{Name: "a", Query: "up", Precision: 2}).series(time.Minute)[0]
+	assert.Equal(t, 12.35, decimals.round(12.345))
+}
+
+func TestPrometheusClientSendsCredentials(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer server.Close()
+
+	source, err := newPrometheusSource(PrometheusConfig{
+		URL:         server.URL,
+		BearerToken: "secret",
+		Headers:     map[string]string{"X-Scope-OrgID": "bench"},
+	})
+	assert.NoError(t, err)
+	_, err = source.queryRange(context.Background(), metricSeries{query: "up"}, time.Unix(0, 0), time.Unix(60, 0), 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+	assert.Equal(t, "bench", header.Get("X-Scope-OrgID"))
+
+	_, err = newPrometheusSource(PrometheusConfig{BearerToken: "secret", Username: "bench"})
+	assert.Error(t, err)
+}
+
+func TestPrometheusSourceFailsOnMultipleSeries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[` +
+			`{"metric":{"pod":"history-0"},"values":[[0,"10"]]},` +
+			`{"metric":{"pod":"history-1"},"values":[[0,"30"]]}]}}`))
+	}))
+	defer server.Close()
+
+	source, err := newPrometheusSource(PrometheusConfig{URL: server.URL})
+	assert.NoError(t, err)
+	_, err = source.queryRange(context.Background(), metricSeries{query: "memory_bytes"}, time.Unix(0, 0), time.Unix(60, 0), 10*time.Second)
+	assert.EqualError(t, err, `query "memory_bytes" matched 2 series, set the aggregation of the metric to combine them`)
+}
+
+func TestBearerTokenFileIsRefreshed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(path, []byte("first\n"), 0600))
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer server.Close()
+
+	source, err := newPrometheusSource(PrometheusConfig{URL: server.URL, BearerTokenFile: path})
+	assert.NoError(t, err)
+	_, err = source.queryRange(context.Background(), metricSeries{query: "up"}, time.Unix(0, 0), time.Unix(60, 0), 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer first", header.Get("Authorization"))
+
+	file := &bearerTokenFile{path: path}
+	now := time.Now()
+	token, err := file.token(now)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	assert.NoError(t, os.WriteFile(path, []byte("second"), 0600))
+	token, err = file.token(now.Add(bearerTokenRefreshInterval / 2))
+	assert.NoError(t, err)
+	assert.Equal(t, "first", token)
+	token, err = file.token(now.Add(bearerTokenRefreshInterval))
+	assert.NoError(t, err)
+	assert.Equal(t, "second", token)
+
+	assert.NoError(t, os.Remove(path))
+	_, err = file.token(now.Add(2 * bearerTokenRefreshInterval))
+	assert.Error(t, err)
+
+	_, err = newPrometheusSource(PrometheusConfig{BearerToken: "secret", BearerTokenFile: path})
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// benchResultVersion is the version of the result document. It changes when a field is removed or changes its meaning.
+const benchResultVersion = 1
+
+// BenchResult is the result document of the bench workflow. It is recorded in the history of the bench workflow,
+// so it can be read without a bench worker once the queries are no longer available.
+type BenchResult struct {
+	Version    int       `json:"version"`
+	WorkflowID string    `json:"workflowId"`
+	RunID      string    `json:"runId"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	// Scenario is the request of the bench workflow as it was received.
+	Scenario          benchWorkflowRequest `json:"scenario"`
+	IntervalInSeconds int                  `json:"intervalInSeconds"`
+	// Histogram, HistogramByWorkflow and Latency are the results of the histogram, histogram_by_workflow and
+	// latency queries.
+	Histogram           []histogramValue            `json:"histogram"`
+	HistogramByWorkflow map[string][]histogramValue `json:"histogramByWorkflow"`
+	Latency             []latencyPercentiles        `json:"latency"`
+	StartLatency        []startLatencyReport        `json:"startLatency"`
+	// Summary holds the totals, the close statuses, the timeline, the search report and the verdict of the run.
+	Summary benchSummary `json:"summary"`
+	// Metrics is the snapshot of the Prometheus metrics. MetricsError is set when they could not be collected.
+	Metrics      []metricValue `json:"metrics"`
+	MetricsError string        `json:"metricsError,omitempty"`
+}
+
+// document builds the result document of a completed run.
+func (w *benchWorkflow) document() BenchResult {
+	res := BenchResult{
+		Version:           benchResultVersion,
+		WorkflowID:        w.baseID,
+		RunID:             w.runID,
+		StartTime:         w.startTime,
+		EndTime:           workflow.Now(w.ctx),
+		Scenario:          w.scenario,
+		IntervalInSeconds: w.request.Report.IntervalInSeconds,
+		StartLatency:      make([]startLatencyReport, len(w.startLatency)),
+		Metrics:           w.metrics,
+		MetricsError:      w.metricsError,
+	}
+	for i := range w.startLatency {
+		res.StartLatency[i] = w.startLatency[i].report()
+	}
+	if w.result != nil {
+		res.Histogram = w.result.Histogram
+		res.HistogramByWorkflow = w.result.Workflows
+		res.Latency = w.result.Latency
+		res.Summary = w.result.Summary
+	}
+	return res
+}
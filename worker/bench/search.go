@@ -0,0 +1,221 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	searchLinear = "linear"
+	searchBinary = "binary"
+)
+
+const defaultLatencyPercentile = "p99"
+
+type (
+	// searchReport is the result of the search mode.
+	searchReport struct {
+		Strategy string `json:"strategy"`
+		// BestRatePerSecond is the highest rate of a step that met the SLO, zero if none did.
+		BestRatePerSecond int                `json:"bestRatePerSecond"`
+		Steps             []searchStepReport `json:"steps"`
+	}
+
+	// searchStepReport is the outcome of a single step of the search.
+	searchStepReport struct {
+		Step          int  `json:"step"`
+		RatePerSecond int  `json:"ratePerSecond"`
+		Started       int  `json:"started"`
+		Passed        bool `json:"passed"`
+		// Reason explains why the step did not meet the SLO.
+		Reason string `json:"reason,omitempty"`
+		// Aborted is set when the abort of the run cut the step short, which leaves it out of the SLO evaluation.
+		Aborted    bool               `json:"aborted,omitempty"`
+		MaxBacklog int                `json:"maxBacklog"`
+		Latency    latencyPercentiles `json:"latency"`
+		Failures   int                `json:"failures"`
+	}
+)
+
+func (s *benchWorkflowRequestSearch) validate() error {
+	switch s.Strategy {
+	case "", searchLinear, searchBinary:
+	default:
+		return errors.Errorf("unknown search strategy %q", s.Strategy)
+	}
+	if s.MinRatePerSecond <= 0 || s.MaxRatePerSecond < s.MinRatePerSecond {
+		return errors.Errorf("search must define a rate range with 0 < minRatePerSecond <= maxRatePerSecond")
+	}
+	if s.StepRatePerSecond <= 0 {
+		return errors.New("search must define stepRatePerSecond")
+	}
+	if s.StepDurationSeconds <= 0 {
+		return errors.New("search must define stepDurationSeconds")
+	}
+	if s.Slo.MaxBacklog == nil && s.Slo.MaxLatencyMs <= 0 && s.Slo.MaxFailures == nil {
+		return errors.New("search must define an SLO with maxBacklog, maxLatencyMs or maxFailures")
+	}
+	if _, ok := (latencyPercentiles{}).get(s.Slo.percentile()); !ok {
+		return errors.Errorf("unknown latency percentile %q", s.Slo.LatencyPercentile)
+	}
+	return nil
+}
+
+func (s *benchWorkflowRequestSlo) percentile() string {
+	if s.LatencyPercentile == "" {
+		return defaultLatencyPercentile
+	}
+	return s.LatencyPercentile
+}
+
+// evaluate checks the outcome of a step against the SLO and records the verdict in the step report.
+func (s *benchWorkflowRequestSlo) evaluate(step *searchStepReport) {
+	var violations []string
+	if s.MaxBacklog != nil && step.MaxBacklog > *s.MaxBacklog {
+		violations = append(violations, fmt.Sprintf("backlog %d exceeds %d", step.MaxBacklog, *s.MaxBacklog))
+	}
+	if s.MaxLatencyMs > 0 {
+		latency, _ := step.Latency.get(s.percentile())
+		if latency > s.MaxLatencyMs {
+			violations = append(violations, fmt.Sprintf("%s latency %.1fms exceeds %.1fms", s.percentile(), latency, s.MaxLatencyMs))
+		}
+	}
+	if s.MaxFailures != nil && step.Failures > *s.MaxFailures {
+		violations = append(violations, fmt.Sprintf("%d failures exceed %d", step.Failures, *s.MaxFailures))
+	}
+	step.Passed = len(violations) == 0
+	step.Reason = strings.Join(violations, ", ")
+}
+
+// runSearch runs a step per probed rate, monitors each of them on its own and keeps track of the highest rate that
+// met the SLO. It returns the number of workflows started by all steps.
+func (w *benchWorkflow) runSearch() (int, error) {
+	search := w.request.Search
+	w.search = &searchReport{Strategy: search.Strategy, Steps: []searchStepReport{}}
+	if w.search.Strategy == "" {
+		w.search.Strategy = searchLinear
+	}
+
+	var started int
+	probe := func(rate int) (bool, bool, error) {
+		running, err := w.awaitResume()
+		if err != nil || !running {
+			return false, false, err
+		}
+		report, err := w.probe(rate)
+		started += report.Started
+		if err != nil || report.Aborted {
+			return false, false, err
+		}
+		return report.Passed, true, nil
+	}
+
+	switch w.search.Strategy {
+	case searchLinear:
+		for rate := search.MinRatePerSecond; rate <= search.MaxRatePerSecond; rate += search.StepRatePerSecond {
+			passed, running, err := probe(rate)
+			if err != nil {
+				return started, err
+			}
+			if !passed || !running {
+				break
+			}
+		}
+	case searchBinary:
+		low, high := search.MinRatePerSecond, search.MaxRatePerSecond
+		for low <= high {
+			rate := low + (high-low)/2
+			passed, running, err := probe(rate)
+			if err != nil {
+				return started, err
+			}
+			if !running {
+				break
+			}
+			if passed {
+				low = rate + search.StepRatePerSecond
+			} else {
+				high = rate - search.StepRatePerSecond
+			}
+		}
+	}
+
+	w.logger.Info("search completed", "bestRatePerSecond", w.search.BestRatePerSecond)
+	return started, nil
+}
+
+// recordProbe evaluates a monitored step of the search against the SLO and adds it to the report. A step cut short
+// by the abort of the run says nothing about its rate, so it neither passes nor fails.
+func (w *benchWorkflow) recordProbe(report *searchStepReport) {
+	report.Aborted = w.control.Aborted
+	if !report.Aborted {
+		w.request.Search.Slo.evaluate(report)
+	}
+	if report.Passed && report.RatePerSecond > w.search.BestRatePerSecond {
+		w.search.BestRatePerSecond = report.RatePerSecond
+	}
+	w.search.Steps = append(w.search.Steps, *report)
+}
+
+// probe runs a step of the search at the given rate and waits for its workflows to complete.
+func (w *benchWorkflow) probe(rate int) (searchStepReport, error) {
+	stepIndex := len(w.request.Steps)
+	step := benchWorkflowRequestStep{
+		DurationSeconds: w.request.Search.StepDurationSeconds,
+		RatePerSecond:   rate,
+	}
+	w.request.Steps = append(w.request.Steps, step)
+	w.step = stepIndex
+	w.stepStartTime = workflow.Now(w.ctx)
+	w.phase = phaseDriving
+	report := searchStepReport{Step: stepIndex, RatePerSecond: rate}
+
+	w.logger.Info("probing rate", "step", stepIndex, "ratePerSecond", rate)
+	count, err := w.executeDriverActivities(stepIndex, step)
+	report.Started = count
+	if err != nil {
+		return report, err
+	}
+
+	w.phase = phaseMonitoring
+	res, err := w.executeMonitorActivity(fmt.Sprintf("%s-%d", w.baseID, stepIndex), w.stepStartTime, count)
+	if err != nil {
+		return report, err
+	}
+	for _, v := range res.Histogram {
+		if v.Backlog > report.MaxBacklog {
+			report.MaxBacklog = v.Backlog
+		}
+	}
+	report.Latency = res.Summary.Latency
+	report.Failures = res.Summary.Statuses.failures()
+	w.recordProbe(&report)
+	w.logger.Info("probed rate", "step", stepIndex, "ratePerSecond", rate, "passed", report.Passed, "reason", report.Reason,
+		"aborted", report.Aborted)
+	return report, nil
+}
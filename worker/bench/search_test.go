@@ -0,0 +1,88 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSloEvaluatePasses(t *testing.T) {
+	maxBacklog := 100
+	slo := benchWorkflowRequestSlo{MaxBacklog: &maxBacklog, MaxLatencyMs: 500}
+	step := searchStepReport{MaxBacklog: 80, Latency: latencyPercentiles{P99: 450, Max: 900}}
+	slo.evaluate(&step)
+	assert.True(t, step.Passed)
+	assert.Empty(t, step.Reason)
+}
+
+func TestSloEvaluateReportsViolations(t *testing.T) {
+	maxBacklog := 100
+	maxFailures := 0
+	slo := benchWorkflowRequestSlo{MaxBacklog: &maxBacklog, MaxLatencyMs: 500, LatencyPercentile: "p50", MaxFailures: &maxFailures}
+	step := searchStepReport{MaxBacklog: 120, Latency: latencyPercentiles{P50: 600}, Failures: 2}
+	slo.evaluate(&step)
+	assert.False(t, step.Passed)
+	assert.Equal(t, "backlog 120 exceeds 100, p50 latency 600.0ms exceeds 500.0ms, 2 failures exceed 0", step.Reason)
+}
+
+func TestRecordProbeSkipsAbortedSteps(t *testing.T) {
+	maxBacklog := 100
+	w := benchWorkflow{
+		request: benchWorkflowRequest{Search: &benchWorkflowRequestSearch{Slo: benchWorkflowRequestSlo{MaxBacklog: &maxBacklog}}},
+		search:  &searchReport{},
+	}
+	w.recordProbe(&searchStepReport{Step: 0, RatePerSecond: 100, MaxBacklog: 10})
+	w.control.Aborted = true
+	w.recordProbe(&searchStepReport{Step: 1, RatePerSecond: 200, MaxBacklog: 10})
+
+	assert.Equal(t, 100, w.search.BestRatePerSecond)
+	assert.Len(t, w.search.Steps, 2)
+	assert.True(t, w.search.Steps[0].Passed)
+	assert.False(t, w.search.Steps[0].Aborted)
+	assert.False(t, w.search.Steps[1].Passed)
+	assert.True(t, w.search.Steps[1].Aborted)
+	assert.Empty(t, w.search.Steps[1].Reason)
+}
+
+func TestSearchValidate(t *testing.T) {
+	maxBacklog := 0
+	search := benchWorkflowRequestSearch{
+		MinRatePerSecond:    10,
+		MaxRatePerSecond:    100,
+		StepRatePerSecond:   10,
+		StepDurationSeconds: 60,
+		Slo:                 benchWorkflowRequestSlo{MaxBacklog: &maxBacklog},
+	}
+	assert.NoError(t, search.validate())
+
+	search.Strategy = "random"
+	assert.Error(t, search.validate())
+
+	search.Strategy = searchBinary
+	search.Slo.LatencyPercentile = "p42"
+	assert.Error(t, search.validate())
+
+	search.Slo = benchWorkflowRequestSlo{}
+	assert.Error(t, search.validate())
+}
@@ -0,0 +1,134 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// startLatencyValue aggregates the workflow starts planned within a single report interval.
+	startLatencyValue struct {
+		// Latency is the duration of the start RPC.
+		Latency latencyHistogram `json:"latency"`
+		// Delay is how late the start RPC was issued compared to its planned start time.
+		Delay latencyHistogram `json:"delay"`
+		// Corrected is the time from the planned start time to the completion of the start RPC,
+		// which is not affected by coordinated omission when the driver falls behind its schedule.
+		Corrected latencyHistogram `json:"corrected"`
+	}
+
+	// startLatencyReport is the summary of start latencies within an interval or the whole run.
+	startLatencyReport struct {
+		Started   int                `json:"started"`
+		Latency   latencyPercentiles `json:"latency"`
+		Delay     latencyPercentiles `json:"delay"`
+		Corrected latencyPercentiles `json:"corrected"`
+	}
+
+	// startLatencyRecorder records the starts of a driver into report intervals by their planned start time.
+	startLatencyRecorder struct {
+		mu       sync.Mutex
+		origin   time.Time
+		interval time.Duration
+		values   []startLatencyValue
+	}
+)
+
+func newStartLatencyRecorder(origin time.Time, interval time.Duration) *startLatencyRecorder {
+	return &startLatencyRecorder{origin: origin, interval: interval}
+}
+
+func (r *startLatencyRecorder) record(planned, actual time.Time, latency time.Duration) {
+	idx := 0
+	if r.interval > 0 && planned.After(r.origin) {
+		idx = int(planned.Sub(r.origin) / r.interval)
+	}
+	delay := actual.Sub(planned)
+	if delay < 0 {
+		delay = 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.values) <= idx {
+		r.values = append(r.values, startLatencyValue{})
+	}
+	v := &r.values[idx]
+	v.Latency.record(latency)
+	v.Delay.record(delay)
+	v.Corrected.record(delay + latency)
+}
+
+func (r *startLatencyRecorder) snapshot() []startLatencyValue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]startLatencyValue(nil), r.values...)
+}
+
+// counts returns the number of recorded starts in each report interval.
+func (r *startLatencyRecorder) counts() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make([]int, len(r.values))
+	for i := range r.values {
+		counts[i] = r.values[i].Latency.Count
+	}
+	return counts
+}
+
+// mergeStartLatency adds the intervals of src to the intervals of dst.
+func mergeStartLatency(dst []startLatencyValue, src []startLatencyValue) []startLatencyValue {
+	for len(dst) < len(src) {
+		dst = append(dst, startLatencyValue{})
+	}
+	for i, v := range src {
+		dst[i].merge(v)
+	}
+	return dst
+}
+
+func (v *startLatencyValue) merge(other startLatencyValue) {
+	v.Latency.merge(other.Latency)
+	v.Delay.merge(other.Delay)
+	v.Corrected.merge(other.Corrected)
+}
+
+func (v *startLatencyValue) report() startLatencyReport {
+	return startLatencyReport{
+		Started:   v.Latency.Count,
+		Latency:   v.Latency.percentiles(),
+		Delay:     v.Delay.percentiles(),
+		Corrected: v.Corrected.percentiles(),
+	}
+}
+
+// totalStartLatency merges all intervals into a summary of the whole run.
+func totalStartLatency(values []startLatencyValue) startLatencyReport {
+	var total startLatencyValue
+	for _, v := range values {
+		total.merge(v)
+	}
+	return total.report()
+}
@@ -23,46 +23,71 @@
 package bench
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
-	"math"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/prometheus/client_golang/api"
-	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
-	"github.com/prometheus/common/model"
 
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
-// Workflow represents the main workflow that executes the overall bench test
-func Workflow(ctx workflow.Context, request benchWorkflowRequest) error {
+// Workflow represents the main workflow that executes the overall bench test. It returns the result document
+// of the run, see BenchResult.
+func Workflow(ctx workflow.Context, request benchWorkflowRequest) (BenchResult, error) {
 	logger := workflow.GetLogger(ctx)
 	w := benchWorkflow{
 		ctx:      ctx,
 		logger:   logger,
 		request:  request,
+		scenario: request,
 		baseID:   workflow.GetInfo(ctx).WorkflowExecution.ID,
+		runID:    workflow.GetInfo(ctx).WorkflowExecution.RunID,
 		deadline: workflow.Now(ctx).Add(workflow.GetInfo(ctx).WorkflowExecutionTimeout),
 	}
-	return w.run()
+	if err := w.run(); err != nil {
+		return BenchResult{}, err
+	}
+	return w.document(), nil
 }
 
 type (
 	benchWorkflowRequestStep struct {
 		// Count is the total number of workflows to execute across all concurrent drivers.
+		// When DurationSeconds is set, Count is optional and caps the number of workflows started in the step.
 		Count int `json:"count"`
+		// DurationSeconds is the time during which drivers keep starting workflows at the given rate.
+		DurationSeconds int `json:"durationSeconds"`
 		// Concurrency defines how many driver activities should be started in parallel.
 		Concurrency int `json:"concurrency"`
 		// RatePerSecond is the maximum number of workflows to start per second.
 		RatePerSecond int `json:"ratePerSecond"`
+		// FromRatePerSecond and ToRatePerSecond turn the step into a linear ramp of the rate over DurationSeconds.
+		FromRatePerSecond int `json:"fromRatePerSecond"`
+		ToRatePerSecond   int `json:"toRatePerSecond"`
+		// Arrival defines how the starts are distributed over time. By default, starts are paced by a rate limiter.
+		Arrival benchWorkflowRequestArrival `json:"arrival"`
+		// MaxInFlight is the number of workflow starts that a single driver keeps in flight at the same time.
+		// When set, Concurrency defaults to a single driver.
+		MaxInFlight int `json:"maxInFlight"`
+		// Workflow overrides the name, task queue or args of the request's workflow for this step.
+		Workflow *benchWorkflowRequestWorkflow `json:"workflow"`
+		// Workflows replaces the workflow mix of the request for this step.
+		Workflows []benchWorkflowRequestWorkflow `json:"workflows"`
+		// Warmup marks the workflows of the step as warm-up: they run but are left out of the summary statistics.
+		Warmup bool `json:"warmup"`
+	}
+	benchWorkflowRequestArrival struct {
+		// Type is the arrival process of an open-loop timeline: "constant", "poisson" or "bursty".
+		Type string `json:"type"`
+		// OnSeconds and OffSeconds define the cycle of a bursty arrival: all starts happen during the on periods.
+		OnSeconds  int `json:"onSeconds"`
+		OffSeconds int `json:"offSeconds"`
 	}
 	benchWorkflowRequestWorkflow struct {
 		// Name is the name of the workflow to run for benchmarking (workflow under test).
@@ -71,17 +96,134 @@ type (
 		TaskQueue string `json:"taskqueue"`
 		// Args is the argument that should be the input of all executions of the workflow under test.
 		Args interface{} `json:"args"`
+		// Weight is the relative share of the workflow in a mix of workflows. Defaults to 1.
+		Weight int `json:"weight"`
+		// Alias names the entry in the workflow IDs and in the per-workflow breakdown of the report, so that a mix
+		// can run the same workflow with different args. Defaults to Name.
+		Alias string `json:"alias"`
 	}
 	benchWorkflowRequestReporting struct {
 		// IntervalInSeconds defines the granularity of the result histogram.
 		IntervalInSeconds int `json:"intervalInSeconds"`
 		// CsvSeparator defines the separator for the CSV report.
 		CsvSeparator string `json:"csvSeparator"`
+		// WarmupSeconds marks the workflows started within the given time from the beginning of the run as warm-up.
+		WarmupSeconds int `json:"warmupSeconds"`
+	}
+	benchWorkflowRequestMonitor struct {
+		// Visibility is "standard" (default) to list the target workflows by type and start time, or "advanced" to
+		// find them with a visibility query on the run search attribute, which the drivers set on every start.
+		Visibility string `json:"visibility"`
+		// RunSearchAttribute is the Keyword search attribute that holds the ID of the bench run in advanced mode.
+		RunSearchAttribute string `json:"runSearchAttribute"`
+	}
+	benchWorkflowRequestTags struct {
+		// SearchAttributes sets the bench run ID, step index, driver index, iteration and scenario name search
+		// attributes on every target workflow.
+		SearchAttributes bool `json:"searchAttributes"`
+		// Memo sets the same information as a memo on every target workflow.
+		Memo bool `json:"memo"`
+		// CustomSearchAttributes are additional search attributes set on every target workflow.
+		CustomSearchAttributes map[string]interface{} `json:"customSearchAttributes"`
+	}
+	benchWorkflowRequestSearch struct {
+		// Strategy is "linear" (default) to raise the rate step by step until a step misses the SLO, or "binary"
+		// to bisect the range of rates.
+		Strategy string `json:"strategy"`
+		// MinRatePerSecond and MaxRatePerSecond bound the searched rates.
+		MinRatePerSecond int `json:"minRatePerSecond"`
+		MaxRatePerSecond int `json:"maxRatePerSecond"`
+		// StepRatePerSecond is the increment of the rate in a linear search and the precision of a binary search.
+		StepRatePerSecond int `json:"stepRatePerSecond"`
+		// StepDurationSeconds is the time during which each step of the search starts workflows at its rate.
+		StepDurationSeconds int `json:"stepDurationSeconds"`
+		// Slo is the objective that a step must meet for its rate to be sustainable.
+		Slo benchWorkflowRequestSlo `json:"slo"`
+	}
+	benchWorkflowRequestSlo struct {
+		// MaxBacklog is the highest number of started but not yet closed workflows allowed in any interval.
+		MaxBacklog *int `json:"maxBacklog"`
+		// MaxLatencyMs is the highest start-to-close latency allowed at the LatencyPercentile.
+		MaxLatencyMs float64 `json:"maxLatencyMs"`
+		// LatencyPercentile is one of "p50", "p90", "p95", "p99" (default), "p999" or "max".
+		LatencyPercentile string `json:"latencyPercentile"`
+		// MaxFailures is the number of workflows that may fail, time out, be terminated or canceled.
+		MaxFailures *int `json:"maxFailures"`
+	}
+	benchWorkflowRequestAssertions struct {
+		// MaxLatencyMs is the highest start-to-close latency of the measured workflows allowed at the LatencyPercentile.
+		MaxLatencyMs float64 `json:"maxLatencyMs"`
+		// LatencyPercentile is one of "p50", "p90", "p95", "p99" (default), "p999" or "max".
+		LatencyPercentile string `json:"latencyPercentile"`
+		// MaxBacklog is the highest number of started but not yet closed workflows allowed in any measured interval.
+		MaxBacklog *int `json:"maxBacklog"`
+		// MinThroughput is the lowest number of measured workflows completed per second.
+		MinThroughput float64 `json:"minThroughput"`
+		// MaxFailureRatio is the highest share of measured workflows that may fail, time out, be terminated or canceled.
+		MaxFailureRatio *float64 `json:"maxFailureRatio"`
+		// Metrics are limits on the cluster metrics of the run.
+		Metrics []benchWorkflowRequestMetricAssertion `json:"metrics"`
+	}
+	benchWorkflowRequestMetricAssertion struct {
+		// Metric is the name of a value of the metrics query, e.g. "historyCpu".
+		Metric string `json:"metric"`
+		// Aggregation combines the values of all intervals: "max" (default) or "avg".
+		Aggregation string   `json:"aggregation"`
+		Min         *float64 `json:"min"`
+		Max         *float64 `json:"max"`
+	}
+	benchWorkflowRequestMetric struct {
+		// Name is the key of the metric in the metrics query.
+		Name string `json:"name"`
+		// Title is the header of the metric in the metrics_csv query, defaults to the name.
+		Title string `json:"title"`
+		// Query is a PromQL expression, or the selector of the buckets of a histogram when Quantile is set.
+		Query string `json:"query"`
+		// Quantile turns the query into the quantile of a histogram, e.g. 0.95.
+		Quantile float64 `json:"quantile"`
+		// Quantiles reports several quantiles of a histogram, each as its own value: "p50", "p90", "p95", "p99",
+		// "p999" or "max".
+		Quantiles []string `json:"quantiles"`
+		// RateWindowSeconds is the window of the rate of a histogram, defaults to the report interval, and to at
+		// least a minute.
+		RateWindowSeconds int `json:"rateWindowSeconds"`
+		// Aggregation aggregates the series of the query: "sum", "min", "max" or "avg". The buckets of a histogram
+		// are summed by default.
+		Aggregation string `json:"aggregation"`
+		// Unit is shown in the CSV header, and Scale converts the values of the query into it, e.g. 1000 from
+		// seconds to milliseconds.
+		Unit  string  `json:"unit"`
+		Scale float64 `json:"scale"`
+		// Precision is the number of decimals of the scaled values, which are rounded to integers by default.
+		Precision int `json:"precision"`
 	}
 	benchWorkflowRequest struct {
-		Steps    []benchWorkflowRequestStep    `json:"steps"`
-		Workflow benchWorkflowRequestWorkflow  `json:"workflow"`
-		Report   benchWorkflowRequestReporting `json:"report"`
+		Steps    []benchWorkflowRequestStep   `json:"steps"`
+		Workflow benchWorkflowRequestWorkflow `json:"workflow"`
+		// Workflows is a weighted mix of workflows to start instead of the single Workflow.
+		Workflows []benchWorkflowRequestWorkflow `json:"workflows"`
+		Report    benchWorkflowRequestReporting  `json:"report"`
+		Monitor   benchWorkflowRequestMonitor    `json:"monitor"`
+		// Name is the name of the scenario, which is used to tag the target workflows.
+		Name string                   `json:"name"`
+		Tags benchWorkflowRequestTags `json:"tags"`
+		// MaxFailures is the number of measured target workflows that may fail, time out, be terminated or canceled
+		// before the bench workflow fails. By default, any number of failures is accepted.
+		MaxFailures *int `json:"maxFailures"`
+		// Search replaces the steps with a search for the highest rate that meets an SLO.
+		Search *benchWorkflowRequestSearch `json:"search"`
+		// Assertions fail the bench workflow with a non-retryable AssertionError when the report violates them.
+		Assertions *benchWorkflowRequestAssertions `json:"assertions"`
+		// Metrics are the cluster metrics of the report. By default, the metrics of a cluster with Cassandra
+		// persistence are reported.
+		Metrics []benchWorkflowRequestMetric `json:"metrics"`
+		// Control makes the drivers poll the control state of the bench workflow, so that they follow the pause,
+		// resume and set_rate signals. The abort signal works without it.
+		Control *benchWorkflowRequestControl `json:"control"`
+	}
+	benchWorkflowRequestControl struct {
+		// PollIntervalSeconds is how often each driver polls the control state. Defaults to 10 seconds.
+		PollIntervalSeconds int `json:"pollIntervalSeconds"`
 	}
 
 	histogramValue struct {
@@ -89,22 +231,60 @@ type (
 		Execution int `json:"execution"`
 		Closed    int `json:"closed"`
 		Backlog   int `json:"backlog"`
+		// WarmupBacklog is the part of the backlog made of warm-up workflows.
+		WarmupBacklog int `json:"warmupBacklog,omitempty"`
+		// Warmup is set for the intervals in which warm-up workflows started.
+		Warmup bool `json:"warmup,omitempty"`
+		// statusCounts breaks down the workflows closed in the interval by their close status.
+		statusCounts
 	}
 
-	metricValue struct {
-		Persistence    *int     `json:"persistence"`
-		HistoryService *int     `json:"historyService"`
-		PersistenceCpu *int     `json:"persistenceCpu"`
-		HistoryCpu     *int     `json:"historyCpu"`
-		HistoryMemory  *float64 `json:"historyMemory"`
+	statusCounts struct {
+		Completed      int `json:"completed"`
+		Failed         int `json:"failed"`
+		TimedOut       int `json:"timedOut"`
+		Terminated     int `json:"terminated"`
+		Canceled       int `json:"canceled"`
+		ContinuedAsNew int `json:"continuedAsNew"`
 	}
 
+	// metricValue holds the values of the metrics in a single interval by their names. A metric without a value
+	// in the interval is nil.
+	metricValue map[string]*float64
+
 	benchWorkflow struct {
-		ctx      workflow.Context
-		logger   log.Logger
-		request  benchWorkflowRequest
-		baseID   string
-		deadline time.Time
+		ctx          workflow.Context
+		logger       log.Logger
+		request      benchWorkflowRequest
+		baseID       string
+		runID        string
+		deadline     time.Time
+		startTime    time.Time
+		startLatency []startLatencyValue
+		// measuredStartLatency excludes the start latencies of the warm-up steps.
+		measuredStartLatency []startLatencyValue
+		// phase, step and drivers track the progress of the run for the progress query.
+		phase         string
+		step          int
+		stepStartTime time.Time
+		// stepConcurrency is the number of drivers of the current step.
+		stepConcurrency int
+		drivers         [][]*driverProgress
+		control         benchControl
+		// driversCtx is the context of the driver activities, which the abort of the run cancels.
+		driversCtx    workflow.Context
+		cancelDrivers workflow.CancelFunc
+		// timeline records the changes made by control signals.
+		timeline []timelineEvent
+		// search is the report of the search mode, nil when the request defines steps.
+		search *searchReport
+		// scenario is the request as it was received, without the defaults and the steps of a search.
+		scenario benchWorkflowRequest
+		// metrics is the snapshot of the cluster metrics of the run, taken after the monitor completes.
+		metrics      []metricValue
+		metricsError string
+		// result is the result of the monitor activity, nil until the monitor completes.
+		result *benchMonitorActivityResult
 	}
 )
 
@@ -112,8 +292,19 @@ func (w *benchWorkflow) run() error {
 	w.logger.Info("bench driver workflow started")
 
 	startTime := workflow.Now(w.ctx)
+	w.startTime = startTime
+	w.phase = phaseDriving
+	w.driversCtx, w.cancelDrivers = workflow.WithCancel(w.ctx)
 
-	if len(w.request.Steps) == 0 {
+	w.receiveProgress()
+	if err := w.receiveControl(); err != nil {
+		return err
+	}
+	if err := w.setupQueries(); err != nil {
+		return err
+	}
+
+	if len(w.request.Steps) == 0 && w.request.Search == nil {
 		return errors.New("request must have at least one step defined")
 	}
 
@@ -121,259 +312,652 @@ func (w *benchWorkflow) run() error {
 		w.request.Report.IntervalInSeconds = 60
 	}
 
+	switch w.request.Monitor.Visibility {
+	case "", visibilityStandard, visibilityAdvanced:
+	default:
+		return errors.Errorf("unknown monitor visibility %q", w.request.Monitor.Visibility)
+	}
+	if w.request.Monitor.RunSearchAttribute == "" {
+		w.request.Monitor.RunSearchAttribute = defaultRunSearchAttribute
+	}
+	if w.request.Control != nil && w.request.Control.PollIntervalSeconds < 0 {
+		return errors.New("control poll interval must not be negative")
+	}
+
 	for i, step := range w.request.Steps {
-		if err := w.executeDriverActivities(i, step); err != nil {
+		if err := step.Arrival.validate(); err != nil {
+			return errors.Wrapf(err, "step %d", i)
+		}
+		mixKeys := map[string]bool{}
+		for _, wf := range w.stepWorkflows(step) {
+			if wf.Name == "" {
+				return errors.Errorf("workflow name must be defined for step %d", i)
+			}
+			if wf.Weight < 0 {
+				return errors.Errorf("workflow %q must not have a negative weight", wf.Name)
+			}
+			if mixKeys[wf.key()] {
+				// the report breaks the workflows down by alias, it cannot tell the entries of a mix apart otherwise
+				return errors.Errorf("workflow %q is defined more than once in the mix of step %d, give the entries distinct aliases", wf.key(), i)
+			}
+			mixKeys[wf.key()] = true
+		}
+	}
+	aliases := map[string]string{}
+	for _, wf := range w.monitoredWorkflows() {
+		if name, ok := aliases[wf.Alias]; ok && name != wf.Name {
+			return errors.Errorf("alias %q is used for both workflow %q and workflow %q", wf.Alias, name, wf.Name)
+		}
+		aliases[wf.Alias] = wf.Name
+	}
+
+	if len(w.request.Metrics) == 0 {
+		w.request.Metrics = defaultMetrics
+	}
+	names := map[string]bool{}
+	for _, m := range w.request.Metrics {
+		if err := m.validate(); err != nil {
+			return err
+		}
+		for _, series := range m.series(w.reportInterval()) {
+			if names[series.name] {
+				return errors.Errorf("metric %q is defined more than once", series.name)
+			}
+			names[series.name] = true
+		}
+	}
+
+	if w.request.Assertions != nil {
+		if err := w.request.Assertions.validate(names); err != nil {
 			return err
 		}
 	}
 
-	res, err := w.executeMonitorActivity(startTime)
+	if w.request.Search != nil {
+		if len(w.request.Steps) > 0 {
+			return errors.New("request must not define both steps and a search")
+		}
+		if err := w.request.Search.validate(); err != nil {
+			return err
+		}
+		mixKeys := map[string]bool{}
+		for _, wf := range w.workflows() {
+			if wf.Name == "" {
+				return errors.New("workflow name must be defined for the search")
+			}
+			if mixKeys[wf.key()] {
+				return errors.Errorf("workflow %q is defined more than once in the mix of the search, give the entries distinct aliases", wf.key())
+			}
+			mixKeys[wf.key()] = true
+		}
+	}
+
+	var started int
+	var err error
+	if w.request.Search != nil {
+		started, err = w.runSearch()
+	} else {
+		started, err = w.runSteps()
+	}
 	if err != nil {
 		return err
 	}
 
-	if err = w.setupQueries(res, startTime); err != nil {
+	w.phase = phaseMonitoring
+	res, err := w.executeMonitorActivity(w.baseID, startTime, started)
+	if err != nil {
 		return err
 	}
+	res.Summary.Aborted = w.control.Aborted
+	res.Summary.Timeline = w.timeline
+	res.Summary.Search = w.search
+	w.snapshotMetrics(res)
+	if w.request.Assertions != nil {
+		verdict := w.request.Assertions.evaluate(res.Summary, res.Histogram, w.metrics)
+		res.Summary.Verdict = &verdict
+	}
+	w.result = &res
+	w.phase = phaseCompleted
+
+	if failures := res.Summary.Statuses.failures(); res.Summary.Statuses.exceed(w.request.MaxFailures) {
+		// same as a TestError, with the result document as details like a failed verdict
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("%d target workflows did not complete successfully, at most %d allowed: %+v",
+				failures, *w.request.MaxFailures, res.Summary.Statuses),
+			"TestError", nil, w.document())
+	}
+
+	if verdict := res.Summary.Verdict; verdict != nil && !verdict.Passed {
+		return verdict.error(w.document())
+	}
 
 	w.logger.Info("bench driver workflow completed")
 	return nil
 }
 
-func (w *benchWorkflow) executeDriverActivities(stepIndex int, step benchWorkflowRequestStep) (finalErr error) {
+// runSteps runs the drivers of the steps one after the other and returns the number of workflows they started.
+func (w *benchWorkflow) runSteps() (int, error) {
+	var started int
+	for i, step := range w.request.Steps {
+		running, err := w.awaitResume()
+		if err != nil {
+			return started, err
+		}
+		if !running {
+			w.logger.Info("skipping the remaining steps of the aborted run", "step", i)
+			break
+		}
+
+		w.step = i
+		w.stepStartTime = workflow.Now(w.ctx)
+		count, err := w.executeDriverActivities(i, step)
+		if err != nil {
+			return started, err
+		}
+		started += count
+	}
+	return started, nil
+}
+
+// executeDriverActivities runs the drivers of a single step and returns the number of workflows they started.
+func (w *benchWorkflow) executeDriverActivities(stepIndex int, step benchWorkflowRequestStep) (started int, finalErr error) {
+	if step.Count <= 0 && step.DurationSeconds <= 0 {
+		return 0, errors.Errorf("step %d must define either count or durationSeconds", stepIndex)
+	}
+
+	isRamp := step.FromRatePerSecond > 0 || step.ToRatePerSecond > 0
+	if isRamp && step.DurationSeconds <= 0 {
+		return 0, errors.Errorf("ramp step %d must define durationSeconds", stepIndex)
+	}
+
+	if step.Arrival.Type != "" && step.RatePerSecond <= 0 && !isRamp {
+		return 0, errors.Errorf("step %d with %q arrival must define a rate", stepIndex, step.Arrival.Type)
+	}
+
+	maxRate := step.RatePerSecond
+	if isRamp {
+		maxRate = step.FromRatePerSecond
+		if step.ToRatePerSecond > maxRate {
+			maxRate = step.ToRatePerSecond
+		}
+	}
+
 	concurrency := 1
 	switch {
 	case step.Concurrency > 0:
 		concurrency = step.Concurrency
-		if step.Count%concurrency != 0 {
-			return errors.Errorf("request count %d must be a multiple of concurrency %d", step.Count, concurrency)
-		}
-	case step.RatePerSecond > 10:
-		concurrency = step.RatePerSecond / 10
+	case step.MaxInFlight > 0:
+		// a single pipelined driver is not limited by the round-trip latency of the starts
+	case maxRate > 10:
+		concurrency = maxRate / 10
+	}
+	if step.Count > 0 && concurrency > step.Count {
+		// every driver must start at least one workflow, a driver with no share of the count would be unlimited
+		concurrency = step.Count
+	}
+
+	var startTime, stopTime time.Time
+	if step.DurationSeconds > 0 {
+		startTime = workflow.Now(w.ctx)
+		stopTime = startTime.Add(time.Duration(step.DurationSeconds) * time.Second)
 	}
 
+	var rampFromRate, rampToRate float64
+	if isRamp {
+		rampFromRate = float64(step.FromRatePerSecond) / float64(concurrency)
+		rampToRate = float64(step.ToRatePerSecond) / float64(concurrency)
+	}
+
+	w.stepConcurrency = concurrency
 	var futures []workflow.Future
 
 	for i := 0; i < concurrency; i++ {
 		futures = append(futures, workflow.ExecuteActivity(
-			w.withActivityOptions(),
+			w.withDriverActivityOptions(),
 			"bench-DriverActivity",
 			benchDriverActivityRequest{
-				BaseID:        fmt.Sprintf("%s-%d-%d", w.baseID, stepIndex, i),
-				BatchSize:     step.Count / concurrency,
-				Rate:          step.RatePerSecond / concurrency,
-				WorkflowName:  w.request.Workflow.Name,
-				TaskQueueName: w.request.Workflow.TaskQueue,
-				Parameters:    w.request.Workflow.Args,
+				Step:                stepIndex,
+				Driver:              i,
+				BaseID:              w.driverBaseID(stepIndex, step, i),
+				BatchSize:           driverBatchSize(step.Count, concurrency, i),
+				Rate:                float64(step.RatePerSecond) / float64(concurrency),
+				StopTime:            stopTime,
+				RampFromRate:        rampFromRate,
+				RampToRate:          rampToRate,
+				StartTime:           startTime,
+				Arrival:             step.Arrival.Type,
+				BurstOn:             time.Duration(step.Arrival.OnSeconds) * time.Second,
+				BurstOff:            time.Duration(step.Arrival.OffSeconds) * time.Second,
+				MaxInFlight:         step.MaxInFlight,
+				ReportStartTime:     w.startTime,
+				ReportInterval:      time.Duration(w.request.Report.IntervalInSeconds) * time.Second,
+				ProgressInterval:    w.progressInterval(step, concurrency),
+				MaxProgressSignals:  maxDriverProgressSignals(concurrency),
+				ControlPollInterval: w.controlPollInterval(),
+				SearchAttributes:    w.searchAttributes(stepIndex, i),
+				Memo:                w.memo(stepIndex, step, i),
+				TagIteration:        w.request.Tags.SearchAttributes,
+				Workflows:           w.stepWorkflows(step),
 			}))
 	}
 
 	for i, f := range futures {
-		err := f.Get(w.ctx, nil)
+		var res benchDriverActivityResult
+		err := f.Get(w.ctx, &res)
+		var canceled *temporal.CanceledError
+		if errors.As(err, &canceled) && w.control.Aborted {
+			// the driver stopped because the run was aborted, the details of the cancellation hold its result
+			if canceled.HasDetails() {
+				if detailsErr := canceled.Details(&res); detailsErr != nil {
+					w.logger.Warn("failed to decode the result of the aborted driver", "Error", detailsErr, "batchID", i)
+				}
+			}
+			err = nil
+		}
 		if err != nil {
-			w.logger.Warn("failed to execute request", "WorkflowName", w.request.Workflow.Name, "Error", err, "batchID", i)
+			w.logger.Warn("failed to execute request", "Error", err, "batchID", i)
 			finalErr = err
 		}
+		started += res.Started
+		w.completeDriver(stepIndex, i, res)
+		w.startLatency = mergeStartLatency(w.startLatency, res.StartLatency)
+		if !step.Warmup {
+			w.measuredStartLatency = mergeStartLatency(w.measuredStartLatency, res.StartLatency)
+		}
 	}
 
-	return finalErr
+	return started, finalErr
 }
 
-func (w *benchWorkflow) executeMonitorActivity(startTime time.Time) (res []histogramValue, err error) {
-	var count int
-	for _, step := range w.request.Steps {
-		count += step.Count
+// maxProgressSignals is the number of progress signals that the drivers of a step send at most together, so that
+// long or highly concurrent steps do not grow the history of the bench workflow without bound.
+const maxProgressSignals = 1000
+
+// progressInterval returns how often each driver of a step signals its progress: once per report interval, or less
+// often when the drivers would run out of signals before the end of the step.
+func (w *benchWorkflow) progressInterval(step benchWorkflowRequestStep, concurrency int) time.Duration {
+	interval := time.Duration(w.request.Report.IntervalInSeconds) * time.Second
+	if signals := maxDriverProgressSignals(concurrency); step.DurationSeconds > 0 && signals > 0 {
+		spread := time.Duration(step.DurationSeconds) * time.Second / time.Duration(signals)
+		if spread > interval {
+			interval = spread
+		}
+	}
+	return interval
+}
+
+// maxDriverProgressSignals returns the share of maxProgressSignals of each driver of a step. The drivers of a step
+// with more drivers than that only report their final progress.
+func maxDriverProgressSignals(concurrency int) int {
+	return maxProgressSignals / concurrency
+}
+
+// driverBatchSize returns the share of the step count of a driver. The remainder of the count is spread
+// over the first drivers, so that the drivers start exactly count workflows together.
+func driverBatchSize(count, concurrency, driverIndex int) int {
+	size := count / concurrency
+	if driverIndex < count%concurrency {
+		size++
+	}
+	return size
+}
+
+// driverBaseID returns the base of the workflow IDs started by a driver. The IDs of warm-up steps are tagged
+// so that the monitor can tell them apart.
+func (w *benchWorkflow) driverBaseID(stepIndex int, step benchWorkflowRequestStep, driverIndex int) string {
+	if step.Warmup {
+		return fmt.Sprintf("%s-%s-%d-%d", w.baseID, warmupTag, stepIndex, driverIndex)
+	}
+	return fmt.Sprintf("%s-%d-%d", w.baseID, stepIndex, driverIndex)
+}
+
+// executeMonitorActivity waits for the workflows with the given base ID to complete and reports on them.
+func (w *benchWorkflow) executeMonitorActivity(baseID string, startTime time.Time, count int) (res benchMonitorActivityResult, err error) {
+	var warmupEndTime time.Time
+	if w.request.Report.WarmupSeconds > 0 {
+		warmupEndTime = startTime.Add(time.Duration(w.request.Report.WarmupSeconds) * time.Second)
 	}
 	err = workflow.ExecuteActivity(
 		w.withActivityOptions(),
 		"bench-MonitorActivity",
 		benchMonitorActivityRequest{
-			WorkflowName:      w.request.Workflow.Name,
+			Workflows:         w.monitoredWorkflows(),
 			StartTime:         startTime,
-			BaseID:            w.baseID,
+			BaseID:            baseID,
 			Count:             count,
 			IntervalInSeconds: w.request.Report.IntervalInSeconds,
+			WarmupEndTime:     warmupEndTime,
+			Query:             w.visibilityQuery(),
 		}).Get(w.ctx, &res)
+	if err == nil {
+		total := w.totalStartLatency()
+		res.Summary.StartLatency = &total
+	}
 	return
 }
 
-func (w *benchWorkflow) setupQueries(res []histogramValue, startTime time.Time) error {
-	if err := workflow.SetQueryHandler(w.ctx, "histogram", func(input []byte) (string, error) {
-		return w.printJson(res), nil
+// searchAttributes returns the search attributes that a driver sets on every target workflow it starts.
+func (w *benchWorkflow) searchAttributes(stepIndex int, driverIndex int) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for name, value := range w.request.Tags.CustomSearchAttributes {
+		attributes[name] = value
+	}
+	if w.request.Monitor.Visibility == visibilityAdvanced || w.request.Tags.SearchAttributes {
+		attributes[w.request.Monitor.RunSearchAttribute] = w.runID
+	}
+	if w.request.Tags.SearchAttributes {
+		attributes[stepSearchAttribute] = stepIndex
+		attributes[driverSearchAttribute] = driverIndex
+		if w.request.Name != "" {
+			attributes[scenarioSearchAttribute] = w.request.Name
+		}
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+// memo returns the memo that a driver sets on every target workflow it starts.
+func (w *benchWorkflow) memo(stepIndex int, step benchWorkflowRequestStep, driverIndex int) map[string]interface{} {
+	if !w.request.Tags.Memo {
+		return nil
+	}
+	return map[string]interface{}{
+		"benchRunId":      w.runID,
+		"benchWorkflowId": w.baseID,
+		"scenario":        w.request.Name,
+		"step":            stepIndex,
+		"driver":          driverIndex,
+		"warmup":          step.Warmup,
+	}
+}
+
+// visibilityQuery returns the advanced visibility query that selects the target workflows of the run.
+func (w *benchWorkflow) visibilityQuery() string {
+	if w.request.Monitor.Visibility != visibilityAdvanced {
+		return ""
+	}
+	return fmt.Sprintf("%s = %s", w.request.Monitor.RunSearchAttribute, quoteVisibilityValue(w.runID))
+}
+
+// quoteVisibilityValue quotes a string value of a visibility query, escaping the quotes and backslashes in it.
+func quoteVisibilityValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// totalStartLatency summarizes the start latencies of the run, excluding the warm-up.
+func (w *benchWorkflow) totalStartLatency() startLatencyReport {
+	values := w.measuredStartLatency
+	if w.request.Report.WarmupSeconds > 0 {
+		// the start latency intervals are counted from the start of the run
+		first := (w.request.Report.WarmupSeconds + w.request.Report.IntervalInSeconds - 1) / w.request.Report.IntervalInSeconds
+		if first > len(values) {
+			first = len(values)
+		}
+		values = values[first:]
+	}
+	return totalStartLatency(values)
+}
+
+// setupQueries registers the query handlers at the start of the run. Until the monitor completes, the histogram
+// queries return the partial histogram of the starts reported by the drivers.
+func (w *benchWorkflow) setupQueries() error {
+	if err := workflow.SetQueryHandler(w.ctx, "progress", func(input []byte) (string, error) {
+		return w.printJson(w.progress()), nil
 	}); err != nil {
 		return err
 	}
 
-	if err := workflow.SetQueryHandler(w.ctx, "histogram_csv", func(input []byte) (string, error) {
-		return w.printHistogramCsv(res), nil
+	if err := workflow.SetQueryHandler(w.ctx, "timeline", func(input []byte) (string, error) {
+		return w.printJson(w.timeline), nil
 	}); err != nil {
 		return err
 	}
 
-	if err := workflow.SetQueryHandler(w.ctx, "metrics", func(input []byte) (string, error) {
-		endTime := startTime.Add(time.Duration(w.request.Report.IntervalInSeconds*len(res)) * time.Second)
-		values, err := w.collectMetrics(startTime, endTime)
+	if err := workflow.SetQueryHandler(w.ctx, "search", func(input []byte) (string, error) {
+		if w.search == nil {
+			return "", errors.New("the request does not define a search")
+		}
+		return w.printJson(w.search), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := workflow.SetQueryHandler(w.ctx, "verdict", func(input []byte) (string, error) {
+		res, err := w.report()
 		if err != nil {
 			return "", err
 		}
+		if res.Summary.Verdict == nil {
+			return "", errors.New("the request does not define assertions")
+		}
+		return w.printJson(res.Summary.Verdict), nil
+	}); err != nil {
+		return err
+	}
 
-		return w.printJson(values), nil
+	if err := workflow.SetQueryHandler(w.ctx, "histogram", func(input []byte) (string, error) {
+		return w.printJson(w.histogram()), nil
 	}); err != nil {
 		return err
 	}
 
-	if err := workflow.SetQueryHandler(w.ctx, "metrics_csv", func(input []byte) (string, error) {
-		endTime := startTime.Add(time.Duration(w.request.Report.IntervalInSeconds*len(res)) * time.Second)
-		values, err := w.collectMetrics(startTime, endTime)
+	if err := workflow.SetQueryHandler(w.ctx, "histogram_csv", func(input []byte) (string, error) {
+		return w.printHistogramCsv(w.histogram()), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := workflow.SetQueryHandler(w.ctx, "latency", func(input []byte) (string, error) {
+		res, err := w.report()
 		if err != nil {
 			return "", err
 		}
+		return w.printJson(map[string]interface{}{
+			"total":      res.Summary.Latency,
+			"byWorkflow": res.Summary.LatencyByWorkflow,
+			"intervals":  res.Latency,
+		}), nil
+	}); err != nil {
+		return err
+	}
 
-		return w.printMetricsCsv(values), nil
+	if err := workflow.SetQueryHandler(w.ctx, "latency_csv", func(input []byte) (string, error) {
+		res, err := w.report()
+		if err != nil {
+			return "", err
+		}
+		return w.printLatencyCsv(res.Latency), nil
 	}); err != nil {
 		return err
 	}
 
-	return nil
-}
+	if err := workflow.SetQueryHandler(w.ctx, "summary", func(input []byte) (string, error) {
+		res, err := w.report()
+		if err != nil {
+			return "", err
+		}
+		return w.printJson(res.Summary), nil
+	}); err != nil {
+		return err
+	}
 
-func (w *benchWorkflow) withActivityOptions() workflow.Context {
-	ao := workflow.ActivityOptions{
-		HeartbeatTimeout:    60 * time.Second,
-		StartToCloseTimeout: w.deadline.Sub(workflow.Now(w.ctx)),
-		TaskQueue:           benchTaskQueue,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:        50 * time.Millisecond,
-			BackoffCoefficient:     1.2,
-			NonRetryableErrorTypes: []string{"TestError"},
-		},
+	if err := workflow.SetQueryHandler(w.ctx, "histogram_by_workflow", func(input []byte) (string, error) {
+		res, err := w.report()
+		if err != nil {
+			return "", err
+		}
+		return w.printJson(res.Workflows), nil
+	}); err != nil {
+		return err
 	}
 
-	return workflow.WithActivityOptions(w.ctx, ao)
-}
+	if err := workflow.SetQueryHandler(w.ctx, "histogram_by_workflow_csv", func(input []byte) (string, error) {
+		res, err := w.report()
+		if err != nil {
+			return "", err
+		}
+		return w.printHistogramByWorkflowCsv(res.Workflows), nil
+	}); err != nil {
+		return err
+	}
 
-func (w *benchWorkflow) collectMetrics(startTime, endTime time.Time) ([]metricValue, error) {
-	updates, err := w.queryPrometheusHistogram("persistence_latency_bucket{type='history'}", startTime, endTime)
-	if err != nil {
-		return nil, errors.Wrapf(err, "query UpdateWorkflowExecution")
+	if err := workflow.SetQueryHandler(w.ctx, "start_latency", func(input []byte) (string, error) {
+		intervals := make([]startLatencyReport, len(w.startLatency))
+		for i := range w.startLatency {
+			intervals[i] = w.startLatency[i].report()
+		}
+		return w.printJson(map[string]interface{}{
+			"total":     w.totalStartLatency(),
+			"intervals": intervals,
+		}), nil
+	}); err != nil {
+		return err
 	}
 
-	appends, err := w.queryPrometheusHistogram("persistence_latency_bucket{type='history'}", startTime, endTime)
-	if err != nil {
-		return nil, errors.Wrapf(err, "query AppendHistoryNodes")
+	if err := workflow.SetQueryHandler(w.ctx, "start_latency_csv", func(input []byte) (string, error) {
+		return w.printStartLatencyCsv(w.startLatency), nil
+	}); err != nil {
+		return err
 	}
 
-	services, err := w.queryPrometheusHistogram("service_latency_bucket{type='history'}", startTime, endTime)
-	if err != nil {
-		return nil, errors.Wrapf(err, "query service latency")
+	if err := workflow.SetQueryHandler(w.ctx, "metrics", func(input []byte) (string, error) {
+		values, err := w.metricsSnapshot()
+		if err != nil {
+			return "", err
+		}
+
+		return w.printJson(values), nil
+	}); err != nil {
+		return err
 	}
 
-	historyCpus, err := w.queryPrometheusValues("sum(rate(container_cpu_usage_seconds_total{container=\"temporal-history\"}[2m]))", startTime, endTime)
-	if err != nil {
-		return nil, errors.Wrapf(err, "query history CPU")
+	if err := workflow.SetQueryHandler(w.ctx, "metrics_csv", func(input []byte) (string, error) {
+		values, err := w.metricsSnapshot()
+		if err != nil {
+			return "", err
+		}
+
+		return w.printMetricsCsv(values), nil
+	}); err != nil {
+		return err
 	}
 
-	historyMem, err := w.queryPrometheusValues("max(container_memory_working_set_bytes{container=\"temporal-history\"})", startTime, endTime)
-	if err != nil {
-		return nil, errors.Wrapf(err, "query history memory")
+	return nil
+}
+
+// report returns the result of the monitor activity, or an error while the run is still in progress.
+func (w *benchWorkflow) report() (*benchMonitorActivityResult, error) {
+	if w.result == nil {
+		return nil, errors.Errorf("the report is not available in the %s phase, query the progress instead", w.phase)
 	}
+	return w.result, nil
+}
 
-	persistenceCpus, err := w.queryPrometheusValues("sum(rate(container_cpu_usage_seconds_total{container=\"cass-cassandra\"}[2m]))", startTime, endTime)
-	if err != nil {
-		return nil, errors.Wrapf(err, "query history CPU")
+// histogram returns the histogram of the monitor, or the partial histogram while the run is in progress.
+func (w *benchWorkflow) histogram() []histogramValue {
+	if w.result == nil {
+		return w.partialHistogram()
 	}
+	return w.result.Histogram
+}
 
-	values := make([]metricValue, len(updates))
-	convert := func(f float64) *int {
-		if math.IsNaN(f) {
-			return nil
-		}
-		res := int(f * 1000)
-		return &res
+// reportInterval is the duration of an interval of the reports.
+func (w *benchWorkflow) reportInterval() time.Duration {
+	return time.Duration(w.request.Report.IntervalInSeconds) * time.Second
+}
+
+// workflows returns the mix of workflows to start.
+func (w *benchWorkflow) workflows() []benchWorkflowRequestWorkflow {
+	if len(w.request.Workflows) > 0 {
+		return w.request.Workflows
 	}
-	for i, update := range updates {
-		storage := update
-		if len(appends) > i {
-			storage = math.Max(update, appends[i])
-		}
-		value := metricValue{Persistence: convert(storage)}
-		if len(services) > i {
-			value.HistoryService = convert(services[i])
+	return []benchWorkflowRequestWorkflow{w.request.Workflow}
+}
+
+// stepWorkflows returns the mix of workflows to start in a step, taking the overrides of the step into account.
+func (w *benchWorkflow) stepWorkflows(step benchWorkflowRequestStep) []benchWorkflowRequestWorkflow {
+	switch {
+	case len(step.Workflows) > 0:
+		return step.Workflows
+	case step.Workflow != nil:
+		wf := w.request.Workflow
+		if step.Workflow.Name != "" {
+			// the alias of the top-level workflow does not carry over to another workflow
+			wf.Name = step.Workflow.Name
+			wf.Alias = ""
 		}
-		if len(persistenceCpus) > i {
-			value.PersistenceCpu = convert(persistenceCpus[i])
+		if step.Workflow.Alias != "" {
+			wf.Alias = step.Workflow.Alias
 		}
-		if len(historyCpus) > i {
-			value.HistoryCpu = convert(historyCpus[i])
+		if step.Workflow.TaskQueue != "" {
+			wf.TaskQueue = step.Workflow.TaskQueue
 		}
-		if len(historyMem) > i {
-			value.HistoryMemory = &historyMem[i]
+		if step.Workflow.Args != nil {
+			wf.Args = step.Workflow.Args
 		}
-		values[i] = value
+		return []benchWorkflowRequestWorkflow{wf}
+	default:
+		return w.workflows()
 	}
-	return values, nil
 }
 
-func (w *benchWorkflow) queryPrometheusValues(query string, startTime, endTime time.Time) ([]float64, error) {
-	matrix, err := w.queryPrometheus(query, startTime, endTime)
-	if err != nil {
-		return nil, err
-	}
-
-	var res []float64
-	for _, sample := range *matrix {
-		for _, value := range sample.Values {
-			res = append(res, float64(value.Value))
+// monitoredWorkflows returns the distinct entries of the mixes of all steps in the order of their definition, with
+// their aliases defaulted to their names.
+func (w *benchWorkflow) monitoredWorkflows() []benchMonitorWorkflow {
+	var workflows []benchMonitorWorkflow
+	seen := map[benchMonitorWorkflow]bool{}
+	for _, step := range w.request.Steps {
+		for _, wf := range w.stepWorkflows(step) {
+			entry := benchMonitorWorkflow{Name: wf.Name, Alias: wf.key()}
+			if !seen[entry] {
+				seen[entry] = true
+				workflows = append(workflows, entry)
+			}
 		}
 	}
-	return res, nil
+	return workflows
 }
 
-func (w *benchWorkflow) queryPrometheusHistogram(metric string, startTime, endTime time.Time) ([]float64, error) {
-	query := fmt.Sprintf("histogram_quantile(0.95,sum(rate(%s[5m])) by (le))", metric)
-	matrix, err := w.queryPrometheus(query, startTime, endTime)
-	if err != nil {
-		return nil, err
+// key returns the alias of the workflow, which defaults to its name.
+func (w *benchWorkflowRequestWorkflow) key() string {
+	if w.Alias != "" {
+		return w.Alias
 	}
+	return w.Name
+}
 
-	var res []float64
-	for _, sample := range *matrix {
-		for _, value := range sample.Values {
-			res = append(res, float64(value.Value))
-		}
+func (w *benchWorkflowRequestWorkflow) weight() int {
+	if w.Weight <= 0 {
+		return 1
 	}
-	return res, nil
+	return w.Weight
 }
 
-func (w *benchWorkflow) queryPrometheus(query string, startTime, endTime time.Time) (*model.Matrix, error) {
-	prometheusURL := os.Getenv("PROMETHEUS_URL")
-	if prometheusURL == "" {
-		prometheusURL = "http://prometheus-server"
+func (w *benchWorkflow) withActivityOptions() workflow.Context {
+	ao := workflow.ActivityOptions{
+		HeartbeatTimeout:    60 * time.Second,
+		StartToCloseTimeout: w.deadline.Sub(workflow.Now(w.ctx)),
+		TaskQueue:           benchTaskQueue,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        50 * time.Millisecond,
+			BackoffCoefficient:     1.2,
+			NonRetryableErrorTypes: []string{"TestError"},
+		},
 	}
-	client, err := api.NewClient(api.Config{
-		Address: prometheusURL,
-	})
 
-	if err != nil {
-		return nil, errors.Wrapf(err, "creating API client")
-	}
-	v1api := v1.NewAPI(client)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	result, _, err := v1api.QueryRange(ctx, query, v1.Range{
-		Start: startTime,
-		End:   endTime,
-		Step:  time.Duration(w.request.Report.IntervalInSeconds) * time.Second,
-	})
-	if err != nil {
-		return nil, errors.Wrapf(err, "query %q", query)
-	}
-	matrix, ok := result.(model.Matrix)
-	if !ok {
-		return nil, errors.New("query yielded no results")
-	}
+	return workflow.WithActivityOptions(w.ctx, ao)
+}
 
-	return &matrix, nil
+// withDriverActivityOptions is withActivityOptions for the driver activities, which the abort of the run cancels.
+// The drivers heartbeat often enough for the cancellation to reach them within half a minute, and the bench
+// workflow waits for them to report what they started.
+func (w *benchWorkflow) withDriverActivityOptions() workflow.Context {
+	ao := workflow.GetActivityOptions(w.withActivityOptions())
+	ao.HeartbeatTimeout = driverHeartbeatTimeout
+	ao.WaitForCancellation = true
+	return workflow.WithActivityOptions(w.driversCtx, ao)
 }
 
 func (w *benchWorkflow) printJson(values interface{}) string {
@@ -385,83 +969,189 @@ func (w *benchWorkflow) printJson(values interface{}) string {
 }
 
 func (w *benchWorkflow) printHistogramCsv(values []histogramValue) string {
-	separator := ";"
+	separator := w.csvSeparator()
+	lines := []string{strings.Join(histogramCsvHeader, separator)}
+	for i, v := range values {
+		lines = append(lines, strings.Join(w.histogramCsvColumns(i, v), separator))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (w *benchWorkflow) printHistogramByWorkflowCsv(values map[string][]histogramValue) string {
+	separator := w.csvSeparator()
+	header := append([]string{"Workflow"}, histogramCsvHeader...)
+	lines := []string{strings.Join(header, separator)}
+	for _, wf := range w.monitoredWorkflows() {
+		for i, v := range values[wf.Alias] {
+			columns := append([]string{wf.Alias}, w.histogramCsvColumns(i, v)...)
+			lines = append(lines, strings.Join(columns, separator))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var histogramCsvHeader = []string{
+	"Time (seconds)",
+	"Workflows Started",
+	"Workflows Started Rate",
+	"Workflows Executions",
+	"Workflows Execution Rate",
+	"Workflow Closed",
+	"Workflow Closed Rate",
+	"Backlog",
+	"Warmup",
+	"Completed",
+	"Failed",
+	"Timed Out",
+	"Terminated",
+	"Canceled",
+	"Continued As New",
+}
+
+func (w *benchWorkflow) histogramCsvColumns(i int, v histogramValue) []string {
+	interval := w.request.Report.IntervalInSeconds
+	return []string{
+		strconv.Itoa((i + 1) * interval),
+		strconv.Itoa(v.Started),
+		fmt.Sprintf("%f", float32(v.Started)/float32(interval)),
+		strconv.Itoa(v.Execution),
+		fmt.Sprintf("%f", float32(v.Execution)/float32(interval)),
+		strconv.Itoa(v.Closed),
+		fmt.Sprintf("%f", float32(v.Closed)/float32(interval)),
+		strconv.Itoa(v.Backlog),
+		strconv.FormatBool(v.Warmup),
+		strconv.Itoa(v.Completed),
+		strconv.Itoa(v.Failed),
+		strconv.Itoa(v.TimedOut),
+		strconv.Itoa(v.Terminated),
+		strconv.Itoa(v.Canceled),
+		strconv.Itoa(v.ContinuedAsNew),
+	}
+}
+
+func (c *statusCounts) add(status enums.WorkflowExecutionStatus) {
+	switch status {
+	case enums.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		c.Completed++
+	case enums.WORKFLOW_EXECUTION_STATUS_FAILED:
+		c.Failed++
+	case enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT:
+		c.TimedOut++
+	case enums.WORKFLOW_EXECUTION_STATUS_TERMINATED:
+		c.Terminated++
+	case enums.WORKFLOW_EXECUTION_STATUS_CANCELED:
+		c.Canceled++
+	case enums.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW:
+		c.ContinuedAsNew++
+	}
+}
+
+// failures is the number of workflows that closed without completing or continuing as new.
+func (c *statusCounts) failures() int {
+	return c.Failed + c.TimedOut + c.Terminated + c.Canceled
+}
+
+// exceed reports whether there are more failures than the given maximum. A nil maximum accepts any number of failures.
+func (c *statusCounts) exceed(maxFailures *int) bool {
+	return maxFailures != nil && c.failures() > *maxFailures
+}
+
+func (w *benchWorkflow) csvSeparator() string {
 	if w.request.Report.CsvSeparator != "" {
-		separator = w.request.Report.CsvSeparator
+		return w.request.Report.CsvSeparator
 	}
+	return ";"
+}
+
+func (w *benchWorkflow) printLatencyCsv(values []latencyPercentiles) string {
+	separator := w.csvSeparator()
 	interval := w.request.Report.IntervalInSeconds
 	header := strings.Join([]string{
 		"Time (seconds)",
 		"Workflows Started",
-		"Workflows Started Rate",
-		"Workflows Executions",
-		"Workflows Execution Rate",
-		"Workflow Closed",
-		"Workflow Closed Rate",
-		"Backlog",
+		"Latency p50 (ms)",
+		"Latency p90 (ms)",
+		"Latency p95 (ms)",
+		"Latency p99 (ms)",
+		"Latency p99.9 (ms)",
+		"Latency Max (ms)",
 	}, separator)
 	lines := []string{header}
 	for i, v := range values {
 		line := strings.Join([]string{
 			strconv.Itoa((i + 1) * interval),
-			strconv.Itoa(v.Started),
-			fmt.Sprintf("%f", float32(v.Started)/float32(interval)),
-			strconv.Itoa(v.Execution),
-			fmt.Sprintf("%f", float32(v.Execution)/float32(interval)),
-			strconv.Itoa(v.Closed),
-			fmt.Sprintf("%f", float32(v.Closed)/float32(interval)),
-			strconv.Itoa(v.Backlog),
+			strconv.Itoa(v.Count),
+			fmt.Sprintf("%f", v.P50),
+			fmt.Sprintf("%f", v.P90),
+			fmt.Sprintf("%f", v.P95),
+			fmt.Sprintf("%f", v.P99),
+			fmt.Sprintf("%f", v.P999),
+			fmt.Sprintf("%f", v.Max),
 		}, separator)
 		lines = append(lines, line)
 	}
 	return strings.Join(lines, "\n")
 }
 
-func (w *benchWorkflow) printMetricsCsv(values []metricValue) string {
-	separator := ";"
-	if w.request.Report.CsvSeparator != "" {
-		separator = w.request.Report.CsvSeparator
-	}
+func (w *benchWorkflow) printStartLatencyCsv(values []startLatencyValue) string {
+	separator := w.csvSeparator()
 	interval := w.request.Report.IntervalInSeconds
 	header := strings.Join([]string{
 		"Time (seconds)",
-		"Persistence Latency (ms)",
-		"History Service Latency (ms)",
-		"Persistence CPU (mcores)",
-		"History Service CPU (mcores)",
-		"History Service Memory Working Set (MB)",
+		"Workflows Started",
+		"Start Latency p50 (ms)",
+		"Start Latency p99 (ms)",
+		"Start Latency Max (ms)",
+		"Start Delay p50 (ms)",
+		"Start Delay p99 (ms)",
+		"Start Delay Max (ms)",
+		"Corrected Start Latency p50 (ms)",
+		"Corrected Start Latency p99 (ms)",
+		"Corrected Start Latency Max (ms)",
 	}, separator)
 	lines := []string{header}
-	for i, v := range values {
-		var pv string
-		if v.Persistence != nil {
-			pv = strconv.Itoa(*v.Persistence)
-		}
-		var hv string
-		if v.HistoryService != nil {
-			hv = strconv.Itoa(*v.HistoryService)
-		}
-		var pcpu string
-		if v.PersistenceCpu != nil {
-			pcpu = strconv.Itoa(*v.PersistenceCpu)
-		}
-		var hcpu string
-		if v.HistoryCpu != nil {
-			hcpu = strconv.Itoa(*v.HistoryCpu)
-		}
-		var hmem string
-		if v.HistoryMemory != nil {
-			hmem = strconv.Itoa(int(*v.HistoryMemory / 1048576.0))
-		}
+	for i := range values {
+		r := values[i].report()
 		line := strings.Join([]string{
 			strconv.Itoa((i + 1) * interval),
-			pv,
-			hv,
-			pcpu,
-			hcpu,
-			hmem,
+			strconv.Itoa(r.Started),
+			fmt.Sprintf("%f", r.Latency.P50),
+			fmt.Sprintf("%f", r.Latency.P99),
+			fmt.Sprintf("%f", r.Latency.Max),
+			fmt.Sprintf("%f", r.Delay.P50),
+			fmt.Sprintf("%f", r.Delay.P99),
+			fmt.Sprintf("%f", r.Delay.Max),
+			fmt.Sprintf("%f", r.Corrected.P50),
+			fmt.Sprintf("%f", r.Corrected.P99),
+			fmt.Sprintf("%f", r.Corrected.Max),
 		}, separator)
 		lines = append(lines, line)
 	}
 	return strings.Join(lines, "\n")
 }
+
+func (w *benchWorkflow) printMetricsCsv(values []metricValue) string {
+	separator := w.csvSeparator()
+	interval := w.request.Report.IntervalInSeconds
+	var series []metricSeries
+	for _, m := range w.request.Metrics {
+		series = append(series, m.series(w.reportInterval())...)
+	}
+	header := []string{"Time (seconds)"}
+	for _, s := range series {
+		header = append(header, s.title)
+	}
+	lines := []string{strings.Join(header, separator)}
+	for i, v := range values {
+		columns := []string{strconv.Itoa((i + 1) * interval)}
+		for _, s := range series {
+			var column string
+			if value, ok := v.get(s.name); ok {
+				column = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+			columns = append(columns, column)
+		}
+		lines = append(lines, strings.Join(columns, separator))
+	}
+	return strings.Join(lines, "\n")
+}
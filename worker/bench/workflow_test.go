@@ -0,0 +1,122 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bench
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/enums/v1"
+	"testing"
+	"time"
+)
+
+func TestStatusCountsFailures(t *testing.T) {
+	var counts statusCounts
+	for _, status := range []enums.WorkflowExecutionStatus{
+		enums.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+		enums.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+		enums.WORKFLOW_EXECUTION_STATUS_FAILED,
+		enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT,
+		enums.WORKFLOW_EXECUTION_STATUS_TIMED_OUT,
+		enums.WORKFLOW_EXECUTION_STATUS_TERMINATED,
+		enums.WORKFLOW_EXECUTION_STATUS_CANCELED,
+		enums.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW,
+	} {
+		counts.add(status)
+	}
+	assert.Equal(t, statusCounts{Completed: 2, Failed: 1, TimedOut: 2, Terminated: 1, Canceled: 1, ContinuedAsNew: 1}, counts)
+	assert.Equal(t, 5, counts.failures())
+}
+
+func TestStatusCountsExceedMaxFailures(t *testing.T) {
+	limit := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		counts      statusCounts
+		maxFailures *int
+		exceed      bool
+	}{
+		{name: "no limit", counts: statusCounts{Failed: 100}, exceed: false},
+		{name: "no failures allowed", counts: statusCounts{Completed: 10, TimedOut: 1}, maxFailures: limit(0), exceed: true},
+		{name: "at the limit", counts: statusCounts{Failed: 1, TimedOut: 1, Terminated: 1}, maxFailures: limit(3), exceed: false},
+		{name: "above the limit", counts: statusCounts{Failed: 1, TimedOut: 2, Terminated: 1}, maxFailures: limit(3), exceed: true},
+		{name: "continued as new is no failure", counts: statusCounts{ContinuedAsNew: 5}, maxFailures: limit(0), exceed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exceed, tt.counts.exceed(tt.maxFailures))
+		})
+	}
+}
+
+func TestVisibilityQueryQuotesTheRunID(t *testing.T) {
+	w := benchWorkflow{runID: "8f0e-42"}
+	assert.Equal(t, "", w.visibilityQuery())
+
+	w.request.Monitor = benchWorkflowRequestMonitor{Visibility: visibilityAdvanced, RunSearchAttribute: "BenchRunId"}
+	assert.Equal(t, "BenchRunId = '8f0e-42'", w.visibilityQuery())
+
+	w.runID = `it's\`
+	assert.Equal(t, `BenchRunId = 'it\'s\\'`, w.visibilityQuery())
+}
+
+func TestMonitoredWorkflowsDefaultTheAliasToTheName(t *testing.T) {
+	w := benchWorkflow{request: benchWorkflowRequest{
+		Workflows: []benchWorkflowRequestWorkflow{
+			{Name: "basic-workflow", Alias: "short"},
+			{Name: "basic-workflow", Alias: "long"},
+		},
+		Steps: []benchWorkflowRequestStep{
+			{},
+			{Workflow: &benchWorkflowRequestWorkflow{Name: "other-workflow"}},
+			{},
+		},
+	}}
+	assert.Equal(t, []benchMonitorWorkflow{
+		{Name: "basic-workflow", Alias: "short"},
+		{Name: "basic-workflow", Alias: "long"},
+		{Name: "other-workflow", Alias: "other-workflow"},
+	}, w.monitoredWorkflows())
+}
+
+func TestProgressSignalsAreBounded(t *testing.T) {
+	w := benchWorkflow{request: benchWorkflowRequest{Report: benchWorkflowRequestReporting{IntervalInSeconds: 10}}}
+	tests := []struct {
+		name        string
+		step        benchWorkflowRequestStep
+		concurrency int
+		interval    time.Duration
+		signals     int
+	}{
+		{name: "short step", step: benchWorkflowRequestStep{DurationSeconds: 600}, concurrency: 10, interval: 10 * time.Second, signals: 100},
+		{name: "long step", step: benchWorkflowRequestStep{DurationSeconds: 36000}, concurrency: 10, interval: 6 * time.Minute, signals: 100},
+		{name: "count step", step: benchWorkflowRequestStep{Count: 1000000}, concurrency: 100, interval: 10 * time.Second, signals: 10},
+		{name: "too many drivers", step: benchWorkflowRequestStep{DurationSeconds: 600}, concurrency: 2000, interval: 10 * time.Second, signals: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.interval, w.progressInterval(tt.step, tt.concurrency))
+			assert.Equal(t, tt.signals, maxDriverProgressSignals(tt.concurrency))
+			assert.LessOrEqual(t, tt.concurrency*maxDriverProgressSignals(tt.concurrency), maxProgressSignals)
+		})
+	}
+}
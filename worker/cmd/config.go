@@ -0,0 +1,591 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+
+	"github.com/temporalio/maru/bench"
+)
+
+// workerNames are the workers that the application can run.
+var workerNames = []string{"bench", "basic", "basic-act"}
+
+// config is the configuration of the application. It is read from a YAML or JSON file, and the environment
+// variables that are set override the values of the file.
+type config struct {
+	Connection connectionConfig  `yaml:"connection"`
+	TLS        frontendTLSConfig `yaml:"tls"`
+	Workers    workersConfig     `yaml:"workers"`
+	Metrics    metricsConfig     `yaml:"metrics"`
+	Logging    loggingConfig     `yaml:"logging"`
+}
+
+type connectionConfig struct {
+	Namespace             string `yaml:"namespace"`
+	FrontendAddress       string `yaml:"frontendAddress"`
+	SkipNamespaceCreation bool   `yaml:"skipNamespaceCreation"`
+}
+
+// certificatesConfig holds the certificates of a TLS connection, either as files or as base-64 encoded data.
+type certificatesConfig struct {
+	CACertFile               string `yaml:"caCertFile"`
+	CACertData               string `yaml:"caCertData"`
+	ClientCertFile           string `yaml:"clientCertFile"`
+	ClientCertData           string `yaml:"clientCertData"`
+	ClientCertPrivateKeyFile string `yaml:"clientCertPrivateKeyFile"`
+	ClientCertPrivateKeyData string `yaml:"clientCertPrivateKeyData"`
+}
+
+type frontendTLSConfig struct {
+	certificatesConfig     `yaml:",inline"`
+	EnableHostVerification bool `yaml:"enableHostVerification"`
+}
+
+type workersConfig struct {
+	// Run lists the workers that the application runs.
+	Run             []string `yaml:"run"`
+	StickyCacheSize int      `yaml:"stickyCacheSize"`
+	// Options apply to all workers, and Overrides to the named workers.
+	Options   workerOptionsConfig            `yaml:"options"`
+	Overrides map[string]workerOptionsConfig `yaml:"overrides"`
+}
+
+// workerOptionsConfig holds the tunable worker.Options. The options that are not set keep their defaults.
+type workerOptionsConfig struct {
+	NumDecisionPollers                      *int     `yaml:"numDecisionPollers"`
+	NumActivityPollers                      *int     `yaml:"numActivityPollers"`
+	MaxConcurrentWorkflowTaskExecutionSize  *int     `yaml:"maxConcurrentWorkflowTaskExecutionSize"`
+	MaxConcurrentActivityExecutionSize      *int     `yaml:"maxConcurrentActivityExecutionSize"`
+	MaxConcurrentLocalActivityExecutionSize *int     `yaml:"maxConcurrentLocalActivityExecutionSize"`
+	TaskQueueActivitiesPerSecond            *float64 `yaml:"taskQueueActivitiesPerSecond"`
+	WorkerActivitiesPerSecond               *float64 `yaml:"workerActivitiesPerSecond"`
+	WorkerLocalActivitiesPerSecond          *float64 `yaml:"workerLocalActivitiesPerSecond"`
+	StickyScheduleToStartTimeoutSeconds     *int     `yaml:"stickyScheduleToStartTimeoutSeconds"`
+}
+
+type metricsConfig struct {
+	Source     string           `yaml:"source"`
+	OTLPFile   string           `yaml:"otlpFile"`
+	Prometheus prometheusConfig `yaml:"prometheus"`
+}
+
+type prometheusConfig struct {
+	URL             string              `yaml:"url"`
+	BearerToken     string              `yaml:"bearerToken"`
+	BearerTokenFile string              `yaml:"bearerTokenFile"`
+	Username        string              `yaml:"username"`
+	Password        string              `yaml:"password"`
+	TenantID        string              `yaml:"tenantId"`
+	Headers         map[string]string   `yaml:"headers"`
+	TimeoutSeconds  int                 `yaml:"timeoutSeconds"`
+	TLS             prometheusTLSConfig `yaml:"tls"`
+}
+
+type prometheusTLSConfig struct {
+	certificatesConfig `yaml:",inline"`
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+}
+
+type loggingConfig struct {
+	// Level is the minimum level of the logged messages: debug, info, warn or error.
+	Level string `yaml:"level"`
+	// Format is console for human-readable logs, or json.
+	Format string `yaml:"format"`
+}
+
+func defaultConfig() *config {
+	return &config{
+		Connection: connectionConfig{
+			Namespace:       client.DefaultNamespace,
+			FrontendAddress: client.DefaultHostPort,
+		},
+		Workers: workersConfig{
+			Run:             workerNames,
+			StickyCacheSize: 2048,
+		},
+		Metrics: metricsConfig{
+			Source: "prometheus",
+			Prometheus: prometheusConfig{
+				URL:            "http://prometheus-server",
+				TimeoutSeconds: 10,
+			},
+		},
+		Logging: loggingConfig{
+			Level:  "debug",
+			Format: "console",
+		},
+	}
+}
+
+// loadConfig reads the config file at the given path, if any, applies the environment variables on top of it
+// and validates the result.
+func loadConfig(path string) (*config, error) {
+	c := defaultConfig()
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("parsing config file %s: %v", path, err)
+		}
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// applyEnv overrides the configuration with the environment variables that are set to a non-empty value.
+func (c *config) applyEnv() error {
+	env := &envReader{}
+	env.string("NAMESPACE", &c.Connection.Namespace)
+	env.string("FRONTEND_ADDRESS", &c.Connection.FrontendAddress)
+	env.bool("SKIP_NAMESPACE_CREATION", &c.Connection.SkipNamespaceCreation)
+
+	env.certificates("TLS_", &c.TLS.certificatesConfig)
+	env.bool("TLS_ENABLE_HOST_VERIFICATION", &c.TLS.EnableHostVerification)
+
+	env.list("RUN_WORKERS", &c.Workers.Run)
+	env.int("STICKY_CACHE_SIZE", &c.Workers.StickyCacheSize)
+	env.workerOptions("", &c.Workers.Options)
+	for _, name := range workerNames {
+		options := c.Workers.Overrides[name]
+		if env.workerOptions(strings.ToUpper(strings.ReplaceAll(name, "-", "_"))+"_", &options) {
+			if c.Workers.Overrides == nil {
+				c.Workers.Overrides = map[string]workerOptionsConfig{}
+			}
+			c.Workers.Overrides[name] = options
+		}
+	}
+
+	env.string("METRICS_SOURCE", &c.Metrics.Source)
+	env.string("METRICS_OTLP_FILE", &c.Metrics.OTLPFile)
+	p := &c.Metrics.Prometheus
+	env.string("PROMETHEUS_URL", &p.URL)
+	env.string("PROMETHEUS_BEARER_TOKEN", &p.BearerToken)
+	env.string("PROMETHEUS_BEARER_TOKEN_FILE", &p.BearerTokenFile)
+	env.string("PROMETHEUS_USERNAME", &p.Username)
+	env.string("PROMETHEUS_PASSWORD", &p.Password)
+	env.string("PROMETHEUS_TENANT_ID", &p.TenantID)
+	env.headers("PROMETHEUS_HEADERS", &p.Headers)
+	env.int("PROMETHEUS_TIMEOUT_SECONDS", &p.TimeoutSeconds)
+	env.certificates("PROMETHEUS_TLS_", &p.TLS.certificatesConfig)
+	env.bool("PROMETHEUS_TLS_INSECURE_SKIP_VERIFY", &p.TLS.InsecureSkipVerify)
+
+	env.string("LOG_LEVEL", &c.Logging.Level)
+	env.string("LOG_FORMAT", &c.Logging.Format)
+	return env.err()
+}
+
+func (c *config) validate() error {
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if c.Connection.Namespace == "" {
+		fail("connection.namespace must not be empty")
+	}
+	if _, _, err := net.SplitHostPort(c.Connection.FrontendAddress); err != nil {
+		fail("connection.frontendAddress %q must be host:port", c.Connection.FrontendAddress)
+	}
+	c.TLS.validate("tls", fail)
+
+	if len(c.Workers.Run) == 0 {
+		fail("workers.run must list at least one worker")
+	}
+	for _, name := range c.Workers.Run {
+		if !isWorkerName(name) {
+			fail("unknown worker %q in workers.run", name)
+		}
+	}
+	if c.Workers.StickyCacheSize <= 0 {
+		fail("workers.stickyCacheSize must be positive")
+	}
+	c.Workers.Options.validate("workers.options", fail)
+	for name, options := range c.Workers.Overrides {
+		if !isWorkerName(name) {
+			fail("unknown worker %q in workers.overrides", name)
+		}
+		options.validate("workers.overrides."+name, fail)
+	}
+
+	m := c.Metrics
+	switch m.Source {
+	case "prometheus":
+	case "otlp-file":
+		if m.OTLPFile == "" {
+			fail("metrics.otlpFile must be set for the otlp-file source")
+		}
+	default:
+		fail("unknown metrics.source %q", m.Source)
+	}
+	if m.Prometheus.BearerToken != "" && m.Prometheus.BearerTokenFile != "" {
+		fail("metrics.prometheus.bearerToken and bearerTokenFile are mutually exclusive")
+	}
+	if (m.Prometheus.BearerToken != "" || m.Prometheus.BearerTokenFile != "") && m.Prometheus.Username != "" {
+		fail("metrics.prometheus bearer token and basic authentication are mutually exclusive")
+	}
+	if m.Prometheus.TimeoutSeconds <= 0 {
+		fail("metrics.prometheus.timeoutSeconds must be positive")
+	}
+	for name := range m.Prometheus.Headers {
+		if strings.TrimSpace(name) == "" {
+			fail("metrics.prometheus.headers must not have an empty name")
+		}
+	}
+	m.Prometheus.TLS.validate("metrics.prometheus.tls", fail)
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		fail("unknown logging.level %q", c.Logging.Level)
+	}
+	if c.Logging.Format != "console" && c.Logging.Format != "json" {
+		fail("unknown logging.format %q", c.Logging.Format)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *certificatesConfig) validate(path string, fail func(format string, args ...interface{})) {
+	for _, field := range []struct{ name, file, data string }{
+		{"caCert", c.CACertFile, c.CACertData},
+		{"clientCert", c.ClientCertFile, c.ClientCertData},
+		{"clientCertPrivateKey", c.ClientCertPrivateKeyFile, c.ClientCertPrivateKeyData},
+	} {
+		if field.file != "" && field.data != "" {
+			fail("%s.%sFile and %sData are mutually exclusive", path, field.name, field.name)
+		}
+	}
+}
+
+func (o *workerOptionsConfig) validate(path string, fail func(format string, args ...interface{})) {
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{
+		{"numDecisionPollers", o.NumDecisionPollers},
+		{"numActivityPollers", o.NumActivityPollers},
+		{"maxConcurrentWorkflowTaskExecutionSize", o.MaxConcurrentWorkflowTaskExecutionSize},
+		{"maxConcurrentActivityExecutionSize", o.MaxConcurrentActivityExecutionSize},
+		{"maxConcurrentLocalActivityExecutionSize", o.MaxConcurrentLocalActivityExecutionSize},
+	} {
+		if field.value != nil && *field.value <= 0 {
+			fail("%s.%s must be positive", path, field.name)
+		}
+	}
+	for _, field := range []struct {
+		name  string
+		value *float64
+	}{
+		{"taskQueueActivitiesPerSecond", o.TaskQueueActivitiesPerSecond},
+		{"workerActivitiesPerSecond", o.WorkerActivitiesPerSecond},
+		{"workerLocalActivitiesPerSecond", o.WorkerLocalActivitiesPerSecond},
+	} {
+		if field.value != nil && *field.value < 0 {
+			fail("%s.%s must not be negative", path, field.name)
+		}
+	}
+	if o.StickyScheduleToStartTimeoutSeconds != nil && *o.StickyScheduleToStartTimeoutSeconds < 0 {
+		fail("%s.stickyScheduleToStartTimeoutSeconds must not be negative", path)
+	}
+}
+
+// merge returns the options with the options that are set in the other options replaced.
+func (o workerOptionsConfig) merge(other workerOptionsConfig) workerOptionsConfig {
+	if other.NumDecisionPollers != nil {
+		o.NumDecisionPollers = other.NumDecisionPollers
+	}
+	if other.NumActivityPollers != nil {
+		o.NumActivityPollers = other.NumActivityPollers
+	}
+	if other.MaxConcurrentWorkflowTaskExecutionSize != nil {
+		o.MaxConcurrentWorkflowTaskExecutionSize = other.MaxConcurrentWorkflowTaskExecutionSize
+	}
+	if other.MaxConcurrentActivityExecutionSize != nil {
+		o.MaxConcurrentActivityExecutionSize = other.MaxConcurrentActivityExecutionSize
+	}
+	if other.MaxConcurrentLocalActivityExecutionSize != nil {
+		o.MaxConcurrentLocalActivityExecutionSize = other.MaxConcurrentLocalActivityExecutionSize
+	}
+	if other.TaskQueueActivitiesPerSecond != nil {
+		o.TaskQueueActivitiesPerSecond = other.TaskQueueActivitiesPerSecond
+	}
+	if other.WorkerActivitiesPerSecond != nil {
+		o.WorkerActivitiesPerSecond = other.WorkerActivitiesPerSecond
+	}
+	if other.WorkerLocalActivitiesPerSecond != nil {
+		o.WorkerLocalActivitiesPerSecond = other.WorkerLocalActivitiesPerSecond
+	}
+	if other.StickyScheduleToStartTimeoutSeconds != nil {
+		o.StickyScheduleToStartTimeoutSeconds = other.StickyScheduleToStartTimeoutSeconds
+	}
+	return o
+}
+
+// workerOptions returns the options of the named worker: its overrides on top of the options of all workers.
+func (c *workersConfig) workerOptions(name string) worker.Options {
+	o := c.Options.merge(c.Overrides[name])
+	intOrDefault := func(value *int, defaultValue int) int {
+		if value == nil {
+			return defaultValue
+		}
+		return *value
+	}
+	floatOrDefault := func(value *float64) float64 {
+		if value == nil {
+			return 0
+		}
+		return *value
+	}
+
+	numDecisionPollers := intOrDefault(o.NumDecisionPollers, 50)
+	return worker.Options{
+		MaxConcurrentWorkflowTaskPollers:        numDecisionPollers,
+		MaxConcurrentActivityTaskPollers:        intOrDefault(o.NumActivityPollers, 8*numDecisionPollers),
+		MaxConcurrentWorkflowTaskExecutionSize:  intOrDefault(o.MaxConcurrentWorkflowTaskExecutionSize, 256),
+		MaxConcurrentLocalActivityExecutionSize: intOrDefault(o.MaxConcurrentLocalActivityExecutionSize, 256),
+		MaxConcurrentActivityExecutionSize:      intOrDefault(o.MaxConcurrentActivityExecutionSize, 256),
+		TaskQueueActivitiesPerSecond:            floatOrDefault(o.TaskQueueActivitiesPerSecond),
+		WorkerActivitiesPerSecond:               floatOrDefault(o.WorkerActivitiesPerSecond),
+		WorkerLocalActivitiesPerSecond:          floatOrDefault(o.WorkerLocalActivitiesPerSecond),
+		StickyScheduleToStartTimeout:            time.Duration(intOrDefault(o.StickyScheduleToStartTimeoutSeconds, 0)) * time.Second,
+	}
+}
+
+// benchConfig returns the metrics configuration of the bench activities.
+func (c *metricsConfig) benchConfig() (bench.MetricsConfig, error) {
+	p := c.Prometheus
+	config := bench.MetricsConfig{
+		Source:   c.Source,
+		OTLPFile: c.OTLPFile,
+		Prometheus: bench.PrometheusConfig{
+			URL:             p.URL,
+			BearerToken:     p.BearerToken,
+			BearerTokenFile: p.BearerTokenFile,
+			Username:        p.Username,
+			Password:        p.Password,
+			Headers:         map[string]string{},
+			Timeout:         time.Duration(p.TimeoutSeconds) * time.Second,
+		},
+	}
+	for name, value := range p.Headers {
+		config.Prometheus.Headers[strings.TrimSpace(name)] = value
+	}
+	if p.TenantID != "" {
+		config.Prometheus.Headers["X-Scope-OrgID"] = p.TenantID
+	}
+
+	tlsConfig, err := getPrometheusTLSConfig(p.TLS)
+	if err != nil {
+		return config, err
+	}
+	config.Prometheus.TLS = tlsConfig
+	return config, nil
+}
+
+// newLogger creates the logger of the application.
+func (c *loggingConfig) newLogger() (*zap.Logger, error) {
+	zapConfig := zap.NewDevelopmentConfig()
+	if c.Format == "json" {
+		zapConfig = zap.NewProductionConfig()
+	}
+	if err := zapConfig.Level.UnmarshalText([]byte(c.Level)); err != nil {
+		return nil, err
+	}
+	return zapConfig.Build()
+}
+
+func isWorkerName(name string) bool {
+	for _, n := range workerNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// envReader overrides configuration values with the environment variables, and collects the values that fail to parse.
+type envReader struct {
+	errs []string
+}
+
+func (e *envReader) lookup(envVarName string) (string, bool) {
+	value := os.Getenv(envVarName)
+	return value, value != ""
+}
+
+func (e *envReader) fail(envVarName, value string, err error) {
+	e.errs = append(e.errs, fmt.Sprintf("invalid %s %q: %v", envVarName, value, err))
+}
+
+func (e *envReader) err() error {
+	if len(e.errs) > 0 {
+		return fmt.Errorf("invalid environment: %s", strings.Join(e.errs, "; "))
+	}
+	return nil
+}
+
+func (e *envReader) string(envVarName string, target *string) {
+	if value, ok := e.lookup(envVarName); ok {
+		*target = value
+	}
+}
+
+func (e *envReader) bool(envVarName string, target *bool) {
+	if value, ok := e.lookup(envVarName); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			e.fail(envVarName, value, err)
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (e *envReader) int(envVarName string, target *int) {
+	if value, ok := e.lookup(envVarName); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			e.fail(envVarName, value, err)
+			return
+		}
+		*target = parsed
+	}
+}
+
+// optionalInt sets the target to the value of the variable, if it is set.
+func (e *envReader) optionalInt(envVarName string, target **int) bool {
+	value, ok := e.lookup(envVarName)
+	if !ok {
+		return false
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(envVarName, value, err)
+		return false
+	}
+	*target = &parsed
+	return true
+}
+
+// optionalFloat sets the target to the value of the variable, if it is set.
+func (e *envReader) optionalFloat(envVarName string, target **float64) bool {
+	value, ok := e.lookup(envVarName)
+	if !ok {
+		return false
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(envVarName, value, err)
+		return false
+	}
+	*target = &parsed
+	return true
+}
+
+// list reads a comma-separated list.
+func (e *envReader) list(envVarName string, target *[]string) {
+	if value, ok := e.lookup(envVarName); ok {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*target = items
+	}
+}
+
+// headers reads a comma-separated list of Name=value pairs, which are added to the target.
+func (e *envReader) headers(envVarName string, target *map[string]string) {
+	value, ok := e.lookup(envVarName)
+	if !ok {
+		return
+	}
+	if *target == nil {
+		*target = map[string]string{}
+	}
+	for _, header := range strings.Split(value, ",") {
+		parts := strings.SplitN(header, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			e.fail(envVarName, header, fmt.Errorf("expected Name=value"))
+			continue
+		}
+		(*target)[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+}
+
+func (e *envReader) certificates(prefix string, target *certificatesConfig) {
+	e.string(prefix+"CA_CERT_FILE", &target.CACertFile)
+	e.string(prefix+"CA_CERT_DATA", &target.CACertData)
+	e.string(prefix+"CLIENT_CERT_FILE", &target.ClientCertFile)
+	e.string(prefix+"CLIENT_CERT_DATA", &target.ClientCertData)
+	e.string(prefix+"CLIENT_CERT_PRIVATE_KEY_FILE", &target.ClientCertPrivateKeyFile)
+	e.string(prefix+"CLIENT_CERT_PRIVATE_KEY_DATA", &target.ClientCertPrivateKeyData)
+}
+
+// workerOptions reads the worker options from the variables with the given prefix, and reports whether any is set.
+func (e *envReader) workerOptions(prefix string, target *workerOptionsConfig) bool {
+	set := false
+	for _, ok := range []bool{
+		e.optionalInt(prefix+"NUM_DECISION_POLLERS", &target.NumDecisionPollers),
+		e.optionalInt(prefix+"NUM_ACTIVITY_POLLERS", &target.NumActivityPollers),
+		e.optionalInt(prefix+"MAX_CONCURRENT_WORKFLOW_TASK_EXECUTION_SIZE", &target.MaxConcurrentWorkflowTaskExecutionSize),
+		e.optionalInt(prefix+"MAX_CONCURRENT_ACTIVITY_EXECUTION_SIZE", &target.MaxConcurrentActivityExecutionSize),
+		e.optionalInt(prefix+"MAX_CONCURRENT_LOCAL_ACTIVITY_EXECUTION_SIZE", &target.MaxConcurrentLocalActivityExecutionSize),
+		e.optionalFloat(prefix+"TASK_QUEUE_ACTIVITIES_PER_SECOND", &target.TaskQueueActivitiesPerSecond),
+		e.optionalFloat(prefix+"WORKER_ACTIVITIES_PER_SECOND", &target.WorkerActivitiesPerSecond),
+		e.optionalFloat(prefix+"WORKER_LOCAL_ACTIVITIES_PER_SECOND", &target.WorkerLocalActivitiesPerSecond),
+		e.optionalInt(prefix+"STICKY_SCHEDULE_TO_START_TIMEOUT_SECONDS", &target.StickyScheduleToStartTimeoutSeconds),
+	} {
+		set = set || ok
+	}
+	return set
+}
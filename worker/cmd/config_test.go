@@ -0,0 +1,124 @@
+// The MIT License
+//
+// Copyright (c) 2021 Temporal Technologies Inc.  All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+connection:
+  namespace: benchtest
+  frontendAddress: temporal-frontend:7233
+workers:
+  run: [bench, basic-act]
+  options:
+    numDecisionPollers: 10
+  overrides:
+    basic-act:
+      numActivityPollers: 400
+      workerActivitiesPerSecond: 250.5
+metrics:
+  source: otlp-file
+  otlpFile: /tmp/metrics.json
+  prometheus:
+    tenantId: bench
+logging:
+  format: json
+`)
+	t.Setenv("FRONTEND_ADDRESS", "127.0.0.1:7233")
+	t.Setenv("BASIC_ACT_MAX_CONCURRENT_ACTIVITY_EXECUTION_SIZE", "1000")
+
+	c, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "benchtest", c.Connection.Namespace)
+	assert.Equal(t, "127.0.0.1:7233", c.Connection.FrontendAddress)
+	assert.Equal(t, []string{"bench", "basic-act"}, c.Workers.Run)
+	assert.Equal(t, 2048, c.Workers.StickyCacheSize)
+	assert.Equal(t, "json", c.Logging.Format)
+
+	bench := c.Workers.workerOptions("bench")
+	assert.Equal(t, 10, bench.MaxConcurrentWorkflowTaskPollers)
+	assert.Equal(t, 80, bench.MaxConcurrentActivityTaskPollers)
+	assert.Equal(t, 256, bench.MaxConcurrentActivityExecutionSize)
+
+	basicAct := c.Workers.workerOptions("basic-act")
+	assert.Equal(t, 10, basicAct.MaxConcurrentWorkflowTaskPollers)
+	assert.Equal(t, 400, basicAct.MaxConcurrentActivityTaskPollers)
+	assert.Equal(t, 1000, basicAct.MaxConcurrentActivityExecutionSize)
+	assert.Equal(t, 250.5, basicAct.WorkerActivitiesPerSecond)
+
+	metrics, err := c.Metrics.benchConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "otlp-file", metrics.Source)
+	assert.Equal(t, map[string]string{"X-Scope-OrgID": "bench"}, metrics.Prometheus.Headers)
+	assert.Equal(t, 10*time.Second, metrics.Prometheus.Timeout)
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{"connection": {"namespace": "benchtest"}, "workers": {"stickyCacheSize": 100}}`)
+	c, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "benchtest", c.Connection.Namespace)
+	assert.Equal(t, 100, c.Workers.StickyCacheSize)
+}
+
+func TestLoadConfigFailsOnInvalidValues(t *testing.T) {
+	_, err := loadConfig(writeConfigFile(t, "config.yaml", "workers:\n  stickyCacheSiz: 100\n"))
+	assert.Error(t, err)
+
+	_, err = loadConfig(writeConfigFile(t, "config.yaml", "workers:\n  run: [bench, target]\n  options:\n    numDecisionPollers: 0\n"))
+	assert.EqualError(t, err, `invalid config: unknown worker "target" in workers.run; workers.options.numDecisionPollers must be positive`)
+
+	t.Setenv("STICKY_CACHE_SIZE", "large")
+	t.Setenv("BENCH_WORKER_ACTIVITIES_PER_SECOND", "fast")
+	_, err = loadConfig("")
+	assert.EqualError(t, err, `invalid environment: invalid STICKY_CACHE_SIZE "large": strconv.Atoi: parsing "large": invalid syntax; `+
+		`invalid BENCH_WORKER_ACTIVITIES_PER_SECOND "fast": strconv.ParseFloat: parsing "fast": invalid syntax`)
+}
+
+func TestLoadExampleConfig(t *testing.T) {
+	c, err := loadConfig("../config.example.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, c.Workers.workerOptions("bench").MaxConcurrentWorkflowTaskPollers)
+	assert.Equal(t, 1000, c.Workers.workerOptions("basic-act").MaxConcurrentActivityExecutionSize)
+}
+
+func TestLoadDockerConfig(t *testing.T) {
+	c, err := loadConfig("../docker/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", c.Connection.Namespace)
+	assert.Equal(t, 100, c.Workers.workerOptions("basic").MaxConcurrentWorkflowTaskPollers)
+	assert.Equal(t, 800, c.Workers.workerOptions("basic").MaxConcurrentActivityTaskPollers)
+}
@@ -28,12 +28,11 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"go.temporal.io/api/serviceerror"
@@ -54,25 +53,42 @@ import (
 )
 
 func main() {
-	logger, err := zap.NewDevelopment()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path of the YAML or JSON config file")
+	flag.Parse()
+
+	c, err := loadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	logger, err := c.Logging.newLogger()
 	if err != nil {
 		panic(err)
 	}
 
 	logger.Info("Zap logger created")
-	namespace := getEnvOrDefaultString(logger, "NAMESPACE", client.DefaultNamespace)
-	hostPort := getEnvOrDefaultString(logger, "FRONTEND_ADDRESS", client.DefaultHostPort)
-	skipNamespaceCreation := getEnvOrDefaultBool(logger, "SKIP_NAMESPACE_CREATION", false)
+	logger.Info("Config loaded",
+		zap.String("file", *configFile),
+		zap.String("namespace", c.Connection.Namespace),
+		zap.String("frontendAddress", c.Connection.FrontendAddress),
+		zap.Strings("workers", c.Workers.Run),
+		zap.String("metricsSource", c.Metrics.Source),
+	)
 
-	tlsConfig, err := getTLSConfig(hostPort, logger)
+	tlsConfig, err := getTLSConfig(c.Connection.FrontendAddress, c.TLS)
 	if err != nil {
 		logger.Fatal("failed to build tls config", zap.Error(err))
 	}
 
-	stickyCacheSize := getEnvOrDefaultInt(logger, "STICKY_CACHE_SIZE", 2048)
-	worker.SetStickyWorkflowCacheSize(stickyCacheSize)
+	metricsConfig, err := c.Metrics.benchConfig()
+	if err != nil {
+		logger.Fatal("failed to build metrics config", zap.Error(err))
+	}
 
-	startWorkers(logger, namespace, hostPort, tlsConfig, skipNamespaceCreation)
+	worker.SetStickyWorkflowCacheSize(c.Workers.StickyCacheSize)
+
+	startWorkers(logger, c, tlsConfig, metricsConfig)
 
 	select {}
 }
@@ -125,62 +141,15 @@ func createNamespaceIfNeeded(logger *zap.Logger, namespace string, hostPort stri
 	}
 }
 
-func getEnvOrDefaultString(logger *zap.Logger, envVarName string, defaultValue string) string {
-	value := os.Getenv(envVarName)
-	if value == "" {
-		logger.Info(fmt.Sprintf("'%s' env variable not set, defaulting to '%s'", envVarName, defaultValue))
-		value = defaultValue
-	} else {
-		logger.Info(fmt.Sprintf("'%s' env variable read as '%s'", envVarName, value))
-	}
-	return value
-}
-
-func getEnvOrDefaultBool(logger *zap.Logger, envVarName string, defaultValue bool) bool {
-	result := defaultValue
-	envValue := os.Getenv(envVarName)
-	switch strings.ToLower(envValue) {
-	case "true":
-		logger.Info(fmt.Sprintf("'%s' env variable set to '%s'", envVarName, envValue))
-		result = true
-	case "false":
-		logger.Info(fmt.Sprintf("'%s' env variable set to '%s'", envVarName, envValue))
-		result = false
-	case "":
-		logger.Info(fmt.Sprintf("'%s' env variable not set, defaulting to '%t'", envVarName, defaultValue))
-	default:
-		logger.Info(fmt.Sprintf("'%s' env variable set to unknown value '%s', defaulting to '%t'", envVarName, envValue, result))
-	}
-	return result
-}
-
-func getEnvOrDefaultInt(logger *zap.Logger, envVarName string, defaultValue int) int {
-	value := defaultValue
-	envValue := os.Getenv(envVarName)
-
-	if envValue == "" {
-		logger.Info(fmt.Sprintf("'%s' env variable not set, defaulting to '%v'", envVarName, defaultValue))
-		value = defaultValue
-	} else {
-		parsedValue, err := strconv.Atoi(envValue)
-		if err != nil {
-			logger.Info(fmt.Sprintf("error parsing '%s' env variable, defaulting to '%v'. err: %v", envVarName, defaultValue, err))
-		} else {
-			value = parsedValue
-		}
-	}
-
-	return value
-}
-
 func startWorkers(
 	logger *zap.Logger,
-	namespace string,
-	hostPort string,
+	c *config,
 	tlsConfig *tls.Config,
-	skipNamespaceCreation bool,
+	metricsConfig bench.MetricsConfig,
 ) {
-	if !skipNamespaceCreation {
+	namespace := c.Connection.Namespace
+	hostPort := c.Connection.FrontendAddress
+	if !c.Connection.SkipNamespaceCreation {
 		createNamespaceIfNeeded(logger, namespace, hostPort, tlsConfig)
 	}
 
@@ -201,18 +170,16 @@ func startWorkers(
 		logger.Fatal("failed to build temporal client", zap.Error(err))
 	}
 
-	workersString := getEnvOrDefaultString(logger, "RUN_WORKERS", "bench,basic,basic-act")
-	workers := strings.Split(workersString, ",")
-
-	for _, workerName := range workers {
+	for _, workerName := range c.Workers.Run {
+		options := buildWorkerOptions(context.Background(), logger, &c.Workers, workerName)
 		var worker worker.Worker
 		switch workerName {
 		case "bench":
-			worker = constructBenchWorker(context.Background(), serviceClient, logger, "temporal-bench")
+			worker = constructBenchWorker(serviceClient, options, "temporal-bench", metricsConfig)
 		case "basic":
-			worker = constructBasicWorker(context.Background(), serviceClient, logger, "temporal-basic")
+			worker = constructBasicWorker(serviceClient, options, "temporal-basic")
 		case "basic-act":
-			worker = constructBasicActWorker(context.Background(), serviceClient, logger, "temporal-basic-act")
+			worker = constructBasicActWorker(serviceClient, options, "temporal-basic-act")
 		default:
 			panic(fmt.Sprintf("unknown worker %q", worker))
 		}
@@ -247,68 +214,91 @@ func newPrometheusScope(logger *zap.Logger, c prometheus.Configuration) tally.Sc
 	return scope
 }
 
-func constructBenchWorker(ctx context.Context, serviceClient client.Client, logger *zap.Logger, taskQueue string) worker.Worker {
-	w := worker.New(serviceClient, taskQueue, buildWorkerOptions(ctx, logger))
+func constructBenchWorker(serviceClient client.Client, options worker.Options, taskQueue string, metricsConfig bench.MetricsConfig) worker.Worker {
+	w := worker.New(serviceClient, taskQueue, options)
 	w.RegisterWorkflowWithOptions(bench.Workflow, workflow.RegisterOptions{Name: "bench-workflow"})
-	w.RegisterActivityWithOptions(bench.NewActivities(serviceClient), activity.RegisterOptions{Name: "bench-"})
+	w.RegisterActivityWithOptions(bench.NewActivities(serviceClient, metricsConfig), activity.RegisterOptions{Name: "bench-"})
 	return w
 }
 
-func constructBasicWorker(ctx context.Context, serviceClient client.Client, logger *zap.Logger, taskQueue string) worker.Worker {
-	w := worker.New(serviceClient, taskQueue, buildWorkerOptions(ctx, logger))
+func constructBasicWorker(serviceClient client.Client, options worker.Options, taskQueue string) worker.Worker {
+	w := worker.New(serviceClient, taskQueue, options)
 	w.RegisterWorkflowWithOptions(basic.Workflow, workflow.RegisterOptions{Name: "basic-workflow"})
 	return w
 }
 
-func constructBasicActWorker(ctx context.Context, serviceClient client.Client, logger *zap.Logger, taskQueue string) worker.Worker {
-	w := worker.New(serviceClient, taskQueue, buildWorkerOptions(ctx, logger))
+func constructBasicActWorker(serviceClient client.Client, options worker.Options, taskQueue string) worker.Worker {
+	w := worker.New(serviceClient, taskQueue, options)
 	w.RegisterActivityWithOptions(basic.Activity, activity.RegisterOptions{Name: "basic-activity"})
 	return w
 }
 
-func buildWorkerOptions(ctx context.Context, logger *zap.Logger) worker.Options {
-	numDecisionPollers := getEnvOrDefaultInt(logger, "NUM_DECISION_POLLERS", 50)
-	logger.Info("Using env config for NUM_DECISION_POLLERS", zap.Int("NUM_DECISION_POLLERS", numDecisionPollers))
-
-	workerOptions := worker.Options{
-		BackgroundActivityContext:               ctx,
-		MaxConcurrentWorkflowTaskPollers:        numDecisionPollers,
-		MaxConcurrentActivityTaskPollers:        8 * numDecisionPollers,
-		MaxConcurrentWorkflowTaskExecutionSize:  256,
-		MaxConcurrentLocalActivityExecutionSize: 256,
-		MaxConcurrentActivityExecutionSize:      256,
-	}
+// buildWorkerOptions returns the options of the named worker.
+func buildWorkerOptions(ctx context.Context, logger *zap.Logger, workers *workersConfig, workerName string) worker.Options {
+	workerOptions := workers.workerOptions(workerName)
+	workerOptions.BackgroundActivityContext = ctx
+	logger.Info("Using worker options",
+		zap.String("worker", workerName),
+		zap.Int("MaxConcurrentWorkflowTaskPollers", workerOptions.MaxConcurrentWorkflowTaskPollers),
+		zap.Int("MaxConcurrentActivityTaskPollers", workerOptions.MaxConcurrentActivityTaskPollers),
+		zap.Int("MaxConcurrentWorkflowTaskExecutionSize", workerOptions.MaxConcurrentWorkflowTaskExecutionSize),
+		zap.Int("MaxConcurrentLocalActivityExecutionSize", workerOptions.MaxConcurrentLocalActivityExecutionSize),
+		zap.Int("MaxConcurrentActivityExecutionSize", workerOptions.MaxConcurrentActivityExecutionSize),
+		zap.Float64("TaskQueueActivitiesPerSecond", workerOptions.TaskQueueActivitiesPerSecond),
+		zap.Float64("WorkerActivitiesPerSecond", workerOptions.WorkerActivitiesPerSecond),
+		zap.Float64("WorkerLocalActivitiesPerSecond", workerOptions.WorkerLocalActivitiesPerSecond),
+		zap.Duration("StickyScheduleToStartTimeout", workerOptions.StickyScheduleToStartTimeout),
+	)
 
 	return workerOptions
 }
 
-func getTLSConfig(hostPort string, logger *zap.Logger) (*tls.Config, error) {
+func getTLSConfig(hostPort string, c frontendTLSConfig) (*tls.Config, error) {
 	host, _, parseErr := net.SplitHostPort(hostPort)
 	if parseErr != nil {
 		return nil, fmt.Errorf("unable to parse hostport properly: %+v", parseErr)
 	}
 
-	caCertData := getEnvOrDefaultString(logger, "TLS_CA_CERT_DATA", "")
-	clientCertData := getEnvOrDefaultString(logger, "TLS_CLIENT_CERT_DATA", "")
-	clientCertPrivateKeyData := getEnvOrDefaultString(logger, "TLS_CLIENT_CERT_PRIVATE_KEY_DATA", "")
-	caCertFile := getEnvOrDefaultString(logger, "TLS_CA_CERT_FILE", "")
-	clientCertFile := getEnvOrDefaultString(logger, "TLS_CLIENT_CERT_FILE", "")
-	clientCertPrivateKeyFile := getEnvOrDefaultString(logger, "TLS_CLIENT_CERT_PRIVATE_KEY_FILE", "")
-	enableHostVerification := getEnvOrDefaultBool(logger, "TLS_ENABLE_HOST_VERIFICATION", false)
-
-	caBytes, err := getTLSBytes(caCertFile, caCertData)
+	cert, caPool, err := getTLSCredentials(c.certificatesConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	certBytes, err := getTLSBytes(clientCertFile, clientCertData)
+	// If we are given arguments to verify either server or client, configure TLS
+	if caPool != nil || cert != nil {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: !c.EnableHostVerification,
+			ServerName:         host,
+		}
+		if caPool != nil {
+			tlsConfig.RootCAs = caPool
+		}
+		if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cert}
+		}
+
+		return tlsConfig, nil
+	}
+
+	return nil, nil
+
+}
+
+// getTLSCredentials loads the client certificate and the CA pool of a TLS connection.
+func getTLSCredentials(c certificatesConfig) (*tls.Certificate, *x509.CertPool, error) {
+	caBytes, err := getTLSBytes(c.CACertFile, c.CACertData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certBytes, err := getTLSBytes(c.ClientCertFile, c.ClientCertData)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	keyBytes, err := getTLSBytes(clientCertPrivateKeyFile, clientCertPrivateKeyData)
+	keyBytes, err := getTLSBytes(c.ClientCertPrivateKeyFile, c.ClientCertPrivateKeyData)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var cert *tls.Certificate
@@ -317,7 +307,7 @@ func getTLSConfig(hostPort string, logger *zap.Logger) (*tls.Config, error) {
 	if len(certBytes) > 0 {
 		clientCert, err := tls.X509KeyPair(certBytes, keyBytes)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		cert = &clientCert
 	}
@@ -325,28 +315,31 @@ func getTLSConfig(hostPort string, logger *zap.Logger) (*tls.Config, error) {
 	if len(caBytes) > 0 {
 		caPool = x509.NewCertPool()
 		if !caPool.AppendCertsFromPEM(caBytes) {
-			return nil, errors.New("unknown failure constructing cert pool for ca")
+			return nil, nil, errors.New("unknown failure constructing cert pool for ca")
 		}
 	}
 
-	// If we are given arguments to verify either server or client, configure TLS
-	if caPool != nil || cert != nil {
-		tlsConfig := &tls.Config{
-			InsecureSkipVerify: !enableHostVerification,
-			ServerName:         host,
-		}
-		if caPool != nil {
-			tlsConfig.RootCAs = caPool
-		}
-		if cert != nil {
-			tlsConfig.Certificates = []tls.Certificate{*cert}
-		}
+	return cert, caPool, nil
+}
 
-		return tlsConfig, nil
+// getPrometheusTLSConfig returns the TLS configuration of the connections to Prometheus, or nil for the system defaults.
+func getPrometheusTLSConfig(c prometheusTLSConfig) (*tls.Config, error) {
+	cert, caPool, err := getTLSCredentials(c.certificatesConfig)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil && caPool == nil && !c.InsecureSkipVerify {
+		return nil, nil
 	}
 
-	return nil, nil
-
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		RootCAs:            caPool,
+	}
+	if cert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+	return tlsConfig, nil
 }
 
 func getTLSBytes(certFile string, certData string) ([]byte, error) {